@@ -5,7 +5,8 @@ package cmd
 
 import (
 	"fmt"
-	"td/core"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,7 +17,14 @@ var dateFlag string
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add task to today's list.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Add a task to today's list, or that of --date.
+
+Due and scheduled dates can be given in words, for example
+"Pay rent due friday" or "Call Bob scheduled:tomorrow". They are stored as
+due:YYYY-MM-DD and scheduled:YYYY-MM-DD. Without the colon only a date, a
+weekday, today, tomorrow or "next <weekday>" is taken, so "due now" stays
+as it is; after it any date from td help dates works.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 
 		date, err := parseDate(dateFlag)
@@ -24,12 +32,17 @@ var addCmd = &cobra.Command{
 			fmt.Println("Error parsing date:", err)
 			return
 		}
+		args[0], err = expandDateAnnotations(args[0])
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			return
+		}
 
-		contains, _ := core.ContainsLine(date, args[0])
+		contains, _ := vault.ContainsLine(date, args[0])
 		if contains != 0 {
 			fmt.Println("This item already exists. Skipping")
 		} else {
-			err := core.AddTask(date, args[0])
+			err := vault.AddTask(date, args[0])
 			if err != nil {
 				fmt.Println("Error appending line to file.")
 			}
@@ -39,19 +52,80 @@ var addCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(addCmd)
-	addCmd.Flags().StringVar(&dateFlag, "date", "today", "Date (today, tomorrow, yesterday, or YYYY-MM-DD)")
+	addCmd.Flags().StringVar(&dateFlag, "date", "today", "Date "+dateFlagHelp)
 }
 
+// parseDate parses a --date style argument with the vault's date parser, so
+// that every command accepts the same dates, see td help dates.
 func parseDate(input string) (time.Time, error) {
-	now := time.Now()
-	switch input {
-	case "today":
-		return now, nil
-	case "tomorrow":
-		return now.AddDate(0, 0, 1), nil
-	case "yesterday":
-		return now.AddDate(0, 0, -1), nil
-	default:
-		return time.Parse("2006-01-02", input)
+	return vault.ParseDate(input)
+}
+
+var (
+	dateAnnotationPattern = regexp.MustCompile(`(?i)^(due|scheduled)(:?)(.*)$`)
+	// bareDatePattern is what a date after a bare "due" or "scheduled" has
+	// to look like, so that "due now" or "due this week" stay prose.
+	bareDatePattern = regexp.MustCompile(`(?i)^(?:\d{4}-\d{2}-\d{2}|today|tomorrow|(?:next )?(?:mon|tue|wed|thu|fri|sat|sun)[a-z]*)$`)
+	wordPattern     = regexp.MustCompile(`\S+`)
+)
+
+// expandDateAnnotations rewrites due and scheduled dates given in words, as
+// in "due next friday" or "scheduled:tomorrow", to due:YYYY-MM-DD and
+// scheduled:YYYY-MM-DD, leaving the rest of the text as it is. After a colon
+// up to three words are tried as a date, longest first, and an annotation
+// like "due:someday" is an error. A bare "due" only takes a date, a weekday,
+// today, tomorrow or "next <weekday>"; anything else is left alone.
+func expandDateAnnotations(text string) (string, error) {
+	spans := wordPattern.FindAllStringIndex(text, -1)
+	word := func(i int) string { return text[spans[i][0]:spans[i][1]] }
+
+	var out strings.Builder
+	last := 0
+	for i := 0; i < len(spans); i++ {
+		m := dateAnnotationPattern.FindStringSubmatch(word(i))
+		if m == nil || (m[2] == "" && m[3] != "") {
+			continue
+		}
+
+		// The date starts after the colon or in the next word.
+		var rest []string
+		if m[3] != "" {
+			rest = append(rest, m[3])
+		}
+		for j := i + 1; j < len(spans) && len(rest) < 3; j++ {
+			rest = append(rest, word(j))
+		}
+		longest := 3
+		if m[2] == "" {
+			longest = 2
+		}
+		found := false
+		for n := longest; n >= 1 && !found; n-- {
+			if n > len(rest) {
+				continue
+			}
+			value := strings.Join(rest[:n], " ")
+			if m[2] == "" && !bareDatePattern.MatchString(value) {
+				continue
+			}
+			date, err := parseDate(value)
+			if err != nil {
+				continue
+			}
+			end := i + n
+			if m[3] != "" {
+				end--
+			}
+			out.WriteString(text[last:spans[i][0]])
+			out.WriteString(strings.ToLower(m[1]) + ":" + date.Format("2006-01-02"))
+			last = spans[end][1]
+			i = end
+			found = true
+		}
+		if !found && m[2] == ":" {
+			return text, fmt.Errorf("invalid %s date %q", strings.ToLower(m[1]), m[3])
+		}
 	}
+	out.WriteString(text[last:])
+	return out.String(), nil
 }
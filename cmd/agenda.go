@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var agendaDays int
+
+var agendaCmd = &cobra.Command{
+	Use:   "agenda",
+	Short: "List open tasks due or scheduled in the coming days",
+	Long: `List the open tasks of the whole vault that are due (due:YYYY-MM-DD) or
+scheduled (scheduled:YYYY-MM-DD) between today and --days days from now,
+grouped by day, after the tasks that are overdue.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		items, err := vault.Agenda(time.Now(), agendaDays)
+		if err != nil {
+			fmt.Println("Error reading agenda:", err)
+			os.Exit(1)
+		}
+		if len(items) == 0 {
+			fmt.Printf("Nothing due in the next %d days\n", agendaDays)
+			return
+		}
+
+		heading := ""
+		for _, item := range items {
+			h := item.When.Format("2006-01-02 Monday")
+			if item.Overdue {
+				h = "Overdue"
+			}
+			if h != heading {
+				if heading != "" {
+					fmt.Println()
+				}
+				fmt.Println(h)
+				heading = h
+			}
+			fmt.Printf("  %-9s  %s  %s\n", item.Kind, strings.TrimSpace(item.Task.Text), helpStyle("("+vaultPath(item.Filename)+")"))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(agendaCmd)
+	agendaCmd.Flags().IntVarP(&agendaDays, "days", "n", 7, "Number of days to look ahead")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var configVaultScope bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change td settings",
+	Long: `Show or change td settings.
+
+Settings are merged in this order, later ones winning: built-in defaults,
+config files ($XDG_CONFIG_DIRS/td/config.toml, $XDG_CONFIG_HOME/td/config.toml
+and config.toml in the vault root), TD_* environment variables, and finally
+command line flags.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := vault.Config()
+		value, err := config.Get(args[0])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting in the user (or, with --vault, the vault) config file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := configFilePath()
+		if err := core.SetConfigValue(path, args[0], args[1]); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print all effective settings",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := vault.Config()
+		for _, key := range core.ConfigKeys() {
+			value, _ := config.Get(key)
+			fmt.Printf("%s = %s\n", key, value)
+		}
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the user (or, with --vault, the vault) config file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(configFilePath())
+	},
+}
+
+func configFilePath() string {
+	if configVaultScope {
+		return core.VaultConfigPath(vault.Config().VaultLoc)
+	}
+	return core.UserConfigPath()
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configListCmd, configPathCmd)
+	configCmd.PersistentFlags().BoolVar(&configVaultScope, "vault", false, "Use the shared config file in the vault root")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Ranges shown on the dashboard.
+const (
+	dashboardDays   = 7  // periods listed in the task report
+	dashboardWeeks  = 12 // weeks in the focus heatmap
+	dashboardOldest = 5  // longest open tasks listed
+)
+
+// openDashboard builds the dashboard: the task report of the last week,
+// then the focus time of the last weeks as a heatmap.
+func (m *model) openDashboard() {
+	today := time.Now()
+	from := today.AddDate(0, 0, 1-dashboardDays)
+
+	var b strings.Builder
+	report, err := m.vault.Report(from, today, today, dashboardOldest)
+	if err != nil {
+		m.setStatus(err)
+		return
+	}
+	writeReport(&b, report, from, today, today)
+
+	stats, err := m.vault.Stats(today.AddDate(0, 0, 1-7*dashboardWeeks), today, "week")
+	if err != nil {
+		m.setStatus(err)
+		return
+	}
+	b.WriteString("\n" + statsTitleStyle(fmt.Sprintf("Focus, last %d weeks", dashboardWeeks)) + "\n")
+	total := stats.Total
+	if total.Sessions == 0 {
+		b.WriteString("  No pomodoro sessions yet.\n")
+	} else {
+		fmt.Fprintf(&b, "  %s in %d sessions, %.0f%% completed\n\n", formatMinutes(total.Focus), total.Sessions, total.CompletionRate()*100)
+		b.WriteString(heatmap(stats.Days))
+	}
+
+	m.dashboard = b.String()
+	m.status = ""
+}
+
+// updateDashboard handles keys while the dashboard is shown.
+func (m model) updateDashboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q", "D":
+		m.dashboard = ""
+	case "r":
+		a := &m
+		a.openDashboard()
+	}
+	return m, nil
+}
+
+func (m model) dashboardView() string {
+	return m.dashboard + "\n" + helpStyle("r: refresh • esc: back")
+}
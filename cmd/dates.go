@@ -0,0 +1,34 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// dateFlagHelp ends the usage of every flag taking a date.
+const dateFlagHelp = "(e.g. today, fri, next mon, +3d, week 42 or YYYY-MM-DD)"
+
+var datesCmd = &cobra.Command{
+	Use:   "dates",
+	Short: "Dates accepted by --date and the other date options",
+	Long: `Every option taking a date, the "go to date" prompt of the TUI (g) and
+due/scheduled dates given to td add accept:
+
+  today, tomorrow, yesterday
+  2024-08-30
+  fri, friday             the next Friday, today included
+  next fri, last fri      the first Friday after or before today
+  this fri                Friday of the current week
+  +3d, -2w, +1m, +1y, +3  offsets in days (the default), weeks, months or years
+  in 3 days, 2 weeks ago
+  next week, last week    Monday of the following or previous week
+  next month, last month  the first of the following or previous month
+  week 42, week 42 2025   Monday of an ISO week
+  2024-W42, 2024-W42-5    an ISO week or a day in it
+  start of week, end of week, start of month, end of month, end of year
+
+With skip_weekend set, dates that fall on a weekend move to Monday (or to
+Friday for the end of a week or month) and tomorrow, yesterday and day
+offsets count working days. Explicit dates and weekday names are kept.`,
+}
+
+func init() {
+	rootCmd.AddCommand(datesCmd)
+}
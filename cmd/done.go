@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"td/core"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var taskDate string
+
+var doneCmd = &cobra.Command{
+	Use:   "done <id|text>",
+	Short: "Mark a task as done",
+	Long: `Mark a task as done.
+
+The task is looked up in the period of --date as :<line>, by its ^id, or by
+text: an exact match wins over a substring match, which wins over a fuzzy
+one. If several tasks match equally well nothing is changed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setTaskStatus(args, true)
+	},
+}
+
+var reopenCmd = &cobra.Command{
+	Use:     "reopen <id|text>",
+	Aliases: []string{"undo", "uncheck"},
+	Short:   "Mark a done task as open again",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setTaskStatus(args, false)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doneCmd, reopenCmd)
+	addTaskDateFlag(doneCmd)
+	addTaskDateFlag(reopenCmd)
+}
+
+func addTaskDateFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&taskDate, "date", "today", "Date of the task's period "+dateFlagHelp)
+}
+
+// resolveTask finds the task named by args in the period of --date, exiting
+// with a readable message if there is no single match.
+func resolveTask(args []string, filter core.TaskFilter) (time.Time, core.Task) {
+	date, err := parseDate(taskDate)
+	if err != nil {
+		fmt.Println("Error parsing date:", err)
+		os.Exit(1)
+	}
+	task, err := vault.FindTask(date, strings.Join(args, " "), filter)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	return date, task
+}
+
+func setTaskStatus(args []string, selected bool) {
+	filter := core.TaskFilter{Status: "open"}
+	if !selected {
+		filter.Status = "done"
+	}
+	date, task := resolveTask(args, filter)
+	if err := vault.UpdateTaskStatus(task.Address(date), selected); err != nil {
+		fmt.Println("Error updating task:", err)
+		os.Exit(1)
+	}
+	task.Selected = selected
+	fmt.Println(strings.TrimSpace(task.String()))
+}
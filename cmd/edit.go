@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"td/core"
 	"time"
@@ -9,15 +10,22 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var copyPrevious bool
+var (
+	copyPrevious bool
+	editDate     string
+)
 
 var editCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit today's task file",
-	Long:  `Open today's task file in your default editor.`,
+	Long:  `Open today's task file, or that of --date, in your default editor.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		date := time.Now()
-		err := core.OpenEditor(date, 1, copyPrevious) // Start at line 1
+		date, err := parseDate(editDate)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		err = vault.OpenEditor(date, 1, copyPrevious) // Start at line 1
 		if err != nil {
 			fmt.Printf("Error opening editor: %v\n", err)
 			os.Exit(1)
@@ -27,5 +35,21 @@ var editCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(editCmd)
-	editCmd.Flags().BoolVarP(&copyPrevious, "copy-previous", "c", false, "Copy content from the previous day's file")
+	editCmd.Flags().StringVar(&editDate, "date", "today", "Date "+dateFlagHelp)
+	editCmd.Flags().BoolVarP(&copyPrevious, "copy-previous", "c", false, "Carry over open tasks from the previous period")
+}
+
+// editorExec opens a period file from the TUI, which hands over the terminal
+// while the editor runs. The editor uses the process's own terminal.
+type editorExec struct {
+	vault *core.Vault
+	date  time.Time
+	line  int
+}
+
+func (e *editorExec) Run() error {
+	return e.vault.OpenEditor(e.date, e.line, false)
 }
+func (e *editorExec) SetStdin(r io.Reader)  {}
+func (e *editorExec) SetStdout(w io.Writer) {}
+func (e *editorExec) SetStderr(w io.Writer) {}
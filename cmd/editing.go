@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inputMode is what the text input below the task list is collecting.
+type inputMode int
+
+const (
+	inputNone inputMode = iota
+	inputAdd
+	inputRename
+	inputGoto
+	inputSearch
+)
+
+var inputPrompts = map[inputMode]string{
+	inputAdd:    "Add: ",
+	inputRename: "Rename: ",
+	inputGoto:   "Go to date: ",
+	inputSearch: "Search: ",
+}
+
+func (m *model) startInput(mode inputMode, value string) tea.Cmd {
+	m.mode = mode
+	m.input.Prompt = inputPrompts[mode]
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return tea.Batch(m.input.Focus(), textinput.Blink)
+}
+
+func (m model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = inputNone
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		mode, value := m.mode, strings.TrimSpace(m.input.Value())
+		m.mode = inputNone
+		m.input.Blur()
+		if value != "" {
+			a := &m
+			a.submitInput(mode, value)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *model) submitInput(mode inputMode, value string) {
+	switch mode {
+	case inputAdd:
+		value, err := expandDateAnnotations(value)
+		if err != nil {
+			m.setStatus(err)
+			return
+		}
+		if err := m.vault.AddTask(m.addDate(), value); err != nil {
+			m.setStatus(err)
+			return
+		}
+		m.Refresh()
+		m.cursorToText(value)
+		m.status = "Added: " + value
+	case inputRename:
+		err := m.vault.RenameTask(m.renaming, value)
+		m.setStatus(err)
+		m.Refresh()
+		if err == nil {
+			m.cursorToText(value)
+			m.status = "Renamed to: " + value
+		}
+	case inputGoto:
+		date, err := m.vault.ParseDate(value)
+		if err != nil {
+			m.setStatus(err)
+			return
+		}
+		m.changeDate(date)
+	case inputSearch:
+		m.search(value)
+	}
+}
+
+// cursorToText moves the cursor to the last visible task with the given text.
+func (m *model) cursorToText(text string) {
+	for i := len(m.rows) - 1; i >= 0; i-- {
+		if m.rows[i].Task.Text == text {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// deleteTask asks for confirmation on the first d and deletes on the second.
+func (m *model) deleteTask(r row, confirmed bool) {
+	task := r.Task
+	if !confirmed {
+		m.confirmDelete = true
+		m.status = "Press d again to delete: " + task.Text
+		return
+	}
+	err := m.vault.RemoveTask(task.Address(r.date))
+	m.setStatus(err)
+	m.Refresh()
+	if err == nil {
+		m.status = "Deleted: " + task.Text
+	}
+}
+
+// editTask runs the structural edits bound to key on the task of r.
+func (m *model) editTask(key string, r row) {
+	task, date := r.Task, r.date
+	var err error
+	var done string
+	switch key {
+	case "K":
+		err, done = m.vault.ShiftTask(task.Address(date), -1), "Moved up"
+	case "J":
+		err, done = m.vault.ShiftTask(task.Address(date), 1), "Moved down"
+	case "tab", ">":
+		err, done = m.vault.IndentTask(task.Address(date)), "Indented"
+	case "shift+tab", "<":
+		err, done = m.vault.OutdentTask(task.Address(date)), "Outdented"
+	case "n":
+		next := m.vault.NextDate(date)
+		err = m.vault.MoveTask(task.Address(date), next)
+		done = "Moved to " + next.Format("2006-01-02")
+	default:
+		return
+	}
+
+	m.setStatus(err)
+	m.Refresh()
+	if err == nil {
+		m.status = done + ": " + task.Text
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"td/core"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listDate   string
+	listFrom   string
+	listTo     string
+	listDone   bool
+	listOpen   bool
+	listTags   []string
+	listText   string
+	listFormat string
+	listView   string
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tasks",
+	Long: `List the tasks of a period, or of every period in a --from/--to range.
+--view week or --view month lists every period of the week or month
+containing --date, so in daily mode a week shows all seven day files.
+
+Output formats:
+  plain     one task per line, prefixed with its date (default)
+  markdown  the task lines grouped under a heading per period, with its
+            done/total count
+  json      an array of task objects
+  csv       a header row followed by one row per task`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, to, err := listRange()
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		if listDone && listOpen {
+			fmt.Println("Error: --done and --open are mutually exclusive")
+			os.Exit(1)
+		}
+
+		filter := core.TaskFilter{Tags: listTags, Text: listText}
+		if listDone {
+			filter.Status = "done"
+		} else if listOpen {
+			filter.Status = "open"
+		}
+
+		periods, err := vault.ListTasks(from, to, filter)
+		if err != nil {
+			fmt.Println("Error listing tasks:", err)
+			os.Exit(1)
+		}
+		if err := writeTasks(os.Stdout, listFormat, periods); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVar(&listDate, "date", "today", "Date "+dateFlagHelp)
+	listCmd.Flags().StringVar(&listFrom, "from", "", "Start of the date range (defaults to --date)")
+	listCmd.Flags().StringVar(&listTo, "to", "", "End of the date range (defaults to --date)")
+	listCmd.Flags().BoolVar(&listDone, "done", false, "Only show completed tasks")
+	listCmd.Flags().BoolVar(&listOpen, "open", false, "Only show open tasks")
+	listCmd.Flags().StringSliceVar(&listTags, "tag", nil, "Only show tasks with this tag (repeatable)")
+	listCmd.Flags().StringVar(&listText, "text", "", "Only show tasks containing this text")
+	listCmd.Flags().StringVarP(&listFormat, "format", "f", "plain", "Output format: plain, markdown, json or csv")
+	listCmd.Flags().StringVar(&listView, "view", "", "List the whole day, week or month containing --date")
+}
+
+func listRange() (time.Time, time.Time, error) {
+	date, err := parseDate(listDate)
+	if err != nil {
+		return date, date, err
+	}
+	switch listView {
+	case "":
+	case core.ZoomDay, core.ZoomWeek, core.ZoomMonth:
+		if listFrom != "" || listTo != "" {
+			return date, date, fmt.Errorf("--view can't be combined with --from or --to")
+		}
+		from, to := core.ViewRange(listView, date)
+		return from, to, nil
+	default:
+		return date, date, fmt.Errorf("unknown view %q: expected day, week or month", listView)
+	}
+
+	from, to := date, date
+	if listFrom != "" {
+		if from, err = parseDate(listFrom); err != nil {
+			return from, to, err
+		}
+	}
+	if listTo != "" {
+		if to, err = parseDate(listTo); err != nil {
+			return from, to, err
+		}
+	}
+	return from, to, nil
+}
+
+// taskJSON is the json and csv representation of a listed task.
+type taskJSON struct {
+	Date      string   `json:"date"`
+	Line      int      `json:"line"`
+	Done      bool     `json:"done"`
+	Depth     int      `json:"depth"`
+	Text      string   `json:"text"`
+	Tags      []string `json:"tags"`
+	Contexts  []string `json:"contexts"`
+	Priority  int      `json:"priority"`
+	Due       string   `json:"due,omitempty"`
+	Scheduled string   `json:"scheduled,omitempty"`
+	ID        string   `json:"id,omitempty"`
+	Pomodoros int      `json:"pomodoros,omitempty"`
+	Focus     int      `json:"focus_minutes,omitempty"`
+}
+
+func newTaskJSON(date time.Time, task core.Task) taskJSON {
+	t := taskJSON{
+		Date:     date.Format("2006-01-02"),
+		Line:     task.LineNumber,
+		Done:     task.Selected,
+		Depth:    task.Depth,
+		Text:     task.Text,
+		Tags:     task.Tags,
+		Contexts: task.Contexts,
+		Priority: task.Priority,
+		ID:       task.ID,
+
+		Pomodoros: task.Pomodoros,
+		Focus:     int(task.Focus.Minutes()),
+	}
+	if t.Tags == nil {
+		t.Tags = []string{}
+	}
+	if t.Contexts == nil {
+		t.Contexts = []string{}
+	}
+	if !task.Due.IsZero() {
+		t.Due = task.Due.Format("2006-01-02")
+	}
+	if !task.Scheduled.IsZero() {
+		t.Scheduled = task.Scheduled.Format("2006-01-02")
+	}
+	return t
+}
+
+func writeTasks(w io.Writer, format string, periods []core.PeriodTasks) error {
+	switch format {
+	case "plain":
+		for _, period := range periods {
+			for _, task := range period.Tasks {
+				checked := " "
+				if task.Selected {
+					checked = "x"
+				}
+				fmt.Fprintf(w, "%s  %s[%s] %s\n", period.Date.Format("2006-01-02"), task.Indent, checked, task.Text)
+			}
+		}
+	case "markdown":
+		first := true
+		for _, period := range periods {
+			if len(period.Tasks) == 0 {
+				continue
+			}
+			if !first {
+				fmt.Fprintln(w)
+			}
+			first = false
+			done, total := period.Progress()
+			fmt.Fprintf(w, "## %s (%d/%d)\n\n", period.Date.Format("2006-01-02"), done, total)
+			for _, task := range period.Tasks {
+				fmt.Fprintln(w, task.String())
+			}
+		}
+	case "json":
+		tasks := []taskJSON{}
+		for _, period := range periods {
+			for _, task := range period.Tasks {
+				tasks = append(tasks, newTaskJSON(period.Date, task))
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tasks)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"date", "line", "done", "depth", "text", "tags", "contexts", "priority", "due", "scheduled", "id", "pomodoros", "focus_minutes"})
+		for _, period := range periods {
+			for _, task := range period.Tasks {
+				t := newTaskJSON(period.Date, task)
+				writer.Write([]string{
+					t.Date, strconv.Itoa(t.Line), strconv.FormatBool(t.Done), strconv.Itoa(t.Depth), t.Text,
+					strings.Join(t.Tags, " "), strings.Join(t.Contexts, " "), strconv.Itoa(t.Priority),
+					t.Due, t.Scheduled, t.ID, strconv.Itoa(t.Pomodoros), strconv.Itoa(t.Focus),
+				})
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown format %q: expected plain, markdown, json or csv", format)
+	}
+	return nil
+}
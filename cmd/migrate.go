@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateTo     string
+	migrateDryRun bool
+	migrateYes    bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Convert the vault to the daily, weekly or monthly layout",
+	Long: `Re-group every period file in the vault into the layout of --to.
+
+Files that end up in the same period are merged in date order. A task that
+is already in the merged file, ignoring carry annotations, is not added
+again, but is checked if any of its copies was done.
+
+The changes are shown as a diff and only written after confirmation (or
+with --yes). Files that are merged away are kept as .bak backups. Set
+interval_mode to the new layout afterwards so td reads it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		migration, err := vault.PlanMigration(migrateTo)
+		if err != nil {
+			fmt.Println("Error planning migration:", err)
+			os.Exit(1)
+		}
+		if len(migration.Changes) == 0 {
+			fmt.Printf("The vault is already in the %s layout\n", migrateTo)
+			return
+		}
+
+		printMigration(migration)
+		if migrateDryRun {
+			return
+		}
+		if !migrateYes && !confirm("Apply these changes?") {
+			fmt.Println("Nothing written")
+			return
+		}
+
+		if err := vault.ApplyMigration(migration); err != nil {
+			fmt.Println("Error migrating vault:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Migrated %d file(s) into %d %s file(s)\n", len(migration.Removed), len(migration.Changes), migrateTo)
+		if vault.Config().IntervalMode != migrateTo {
+			fmt.Printf("Run `td config set interval_mode %s` to use the new layout\n", migrateTo)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "Layout to migrate to: daily, weekly or monthly")
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Only show the changes")
+	migrateCmd.Flags().BoolVarP(&migrateYes, "yes", "y", false, "Apply the changes without asking")
+	migrateCmd.MarkFlagRequired("to")
+}
+
+func printMigration(migration *core.Migration) {
+	for _, change := range migration.Changes {
+		var sources []string
+		for _, source := range change.Sources {
+			sources = append(sources, vaultPath(source))
+		}
+		old := "/dev/null"
+		if change.Old != nil {
+			old = vaultPath(change.Filename)
+		}
+		fmt.Printf("--- %s\n+++ %s (from %s)\n", old, vaultPath(change.Filename), strings.Join(sources, ", "))
+		for _, line := range core.DiffLines(string(change.Old), string(change.New), 3) {
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+	for _, change := range migration.Removed {
+		fmt.Printf("removed %s (kept as %s.bak)\n", vaultPath(change.Filename), filepath.Base(change.Filename))
+	}
+}
+
+// vaultPath shows filename relative to the vault root.
+func vaultPath(filename string) string {
+	if rel, err := filepath.Rel(vault.Config().VaultLoc, filename); err == nil {
+		return rel
+	}
+	return filename
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
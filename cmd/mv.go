@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var mvTo string
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <id|text> --to <date>",
+	Short: "Move a task and its subtasks to another period",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		to, err := parseDate(mvTo)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		date, task := resolveTask(args, core.TaskFilter{})
+		if err := vault.MoveTask(task.Address(date), to); err != nil {
+			fmt.Println("Error moving task:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Moved to %s: %s\n", to.Format("2006-01-02"), task.Text)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+	addTaskDateFlag(mvCmd)
+	mvCmd.Flags().StringVar(&mvTo, "to", "", "Date to move the task to "+dateFlagHelp)
+	mvCmd.MarkFlagRequired("to")
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"td/core"
 	"time"
 
@@ -13,16 +15,78 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var duration int
+var (
+	duration       int
+	pomoShortBreak int
+	pomoLongBreak  int
+	pomoEvery      int
+	pomoAutoStart  bool
+	pomoCategory   string
+	pomoTask       string
+	pomoResume     bool
+)
 
 var pomoCmd = &cobra.Command{
 	Use:   "pomo",
 	Short: "Start a Pomodoro timer",
-	Long:  `Start a Pomodoro timer for focused work sessions. Default duration is 25 minutes.`,
+	Long: `Start a Pomodoro timer for focused work sessions.
+
+The timer cycles through 25 minute work phases with 5 minute short breaks,
+and a 15 minute long break after every 4th work phase, until you quit. Each
+phase ends with a notification, and the next one waits for enter unless
+--auto is set. The lengths default to the pomo_* settings (see td config).
+
+Every work phase, finished or not, is appended to sessions.json in the vault
+with its start time, planned and actual length, pauses and category.
+
+With --task the sessions are counted against a task of the period of --date,
+looked up like td done does: a completed session appends a 🍅 to the task
+line, and every session adds to its focus:<minutes>m annotation. In the TUI,
+p starts a timer on the highlighted task.
+
+The running timer is saved to pomo.json in the vault as it goes, so that
+--resume continues it after the terminal was closed or the process killed.
+Time the machine spends asleep, and the time since the timer was last seen
+when resuming, count as pauses rather than focus. Starting a new timer over
+an interrupted one records its work phase as abandoned.`,
+	Example: `  td pomo -d 50 --short 10 -c writing
+  td pomo --task "Write report" --auto
+  td pomo --resume`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		m := initialPomoModel()
-		if _, err := tea.NewProgram(m).Run(); err != nil {
-			fmt.Println("Error running program:", err)
+		if pomoResume {
+			if err := resumePomo(os.Stdin, os.Stdout); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		config := vault.CycleConfig()
+		flags := cmd.Flags()
+		if flags.Changed("duration") {
+			config.Work = time.Duration(duration) * time.Minute
+		}
+		if flags.Changed("short") {
+			config.ShortBreak = time.Duration(pomoShortBreak) * time.Minute
+		}
+		if flags.Changed("long") {
+			config.LongBreak = time.Duration(pomoLongBreak) * time.Minute
+		}
+		if flags.Changed("every") {
+			config.LongBreakEvery = pomoEvery
+		}
+		if flags.Changed("auto") {
+			config.AutoStart = pomoAutoStart
+		}
+
+		var target *pomoTarget
+		if pomoTask != "" {
+			date, task := resolveTask([]string{pomoTask}, core.TaskFilter{Status: "open"})
+			target = &pomoTarget{date: date, task: task}
+		}
+		if err := runPomo(config, target, os.Stdin, os.Stdout); err != nil {
+			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
 	},
@@ -30,41 +94,198 @@ var pomoCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(pomoCmd)
-	pomoCmd.Flags().IntVarP(&duration, "duration", "d", 25, "Duration in minutes")
+	pomoCmd.Flags().IntVarP(&duration, "duration", "d", 25, "Work phase length in minutes")
+	pomoCmd.Flags().IntVar(&pomoShortBreak, "short", 5, "Short break length in minutes")
+	pomoCmd.Flags().IntVar(&pomoLongBreak, "long", 15, "Long break length in minutes")
+	pomoCmd.Flags().IntVar(&pomoEvery, "every", 4, "Work phases before a long break")
+	pomoCmd.Flags().BoolVar(&pomoAutoStart, "auto", false, "Start the next phase without waiting for enter")
+	pomoCmd.Flags().StringVarP(&pomoCategory, "category", "c", "", "Category to record the session under")
+	pomoCmd.Flags().StringVar(&pomoTask, "task", "", "Count the session against this task (:<line>, ^id or text)")
+	pomoCmd.Flags().BoolVar(&pomoResume, "resume", false, "Continue the interrupted timer")
+	addTaskDateFlag(pomoCmd)
+	// A resumed timer keeps the settings it was started with.
+	for _, name := range []string{"duration", "short", "long", "every", "auto", "category", "task", "date"} {
+		pomoCmd.MarkFlagsMutuallyExclusive("resume", name)
+	}
+}
+
+// pomoTarget is the task a pomodoro session is counted against.
+type pomoTarget struct {
+	date time.Time // of the period file the task is in
+	task core.Task
+}
+
+// runPomo runs a pomodoro cycle on in and out until it is quit. A timer
+// interrupted earlier is recorded and replaced, unless it is still running
+// elsewhere, in which case this one is not saved.
+func runPomo(config core.CycleConfig, target *pomoTarget, in io.Reader, out io.Writer) error {
+	m := initialPomoModel(config, target)
+	release, ok, err := vault.ClaimTimer()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		m.status = "Another timer is running, this one can't be resumed"
+		return m.run(in, out)
+	}
+	defer release()
+
+	previous, err := vault.LoadTimer()
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if err := abandonTimer(previous); err != nil {
+			return err
+		}
+		m.status = fmt.Sprintf("The timer interrupted at %s was recorded as abandoned", previous.Heartbeat.Local().Format("15:04"))
+	}
+	m.persist = true
+	m.save()
+	return m.run(in, out)
 }
 
+// resumePomo continues the timer saved in the vault.
+func resumePomo(in io.Reader, out io.Writer) error {
+	release, ok, err := vault.ClaimTimer()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("the timer is still running in another terminal")
+	}
+	defer release()
+
+	timer, err := vault.LoadTimer()
+	if err != nil {
+		return err
+	}
+	if timer == nil {
+		return fmt.Errorf("no interrupted timer to resume")
+	}
+
+	m := resumedPomoModel(timer)
+	m.persist = true
+	m.status = "Resumed"
+	if away := timer.Recover(time.Now()); away >= time.Minute {
+		m.status = fmt.Sprintf("Resumed, %s away not counted", formatMinutes(away))
+	}
+	m.save()
+	return m.run(in, out)
+}
+
+// abandonTimer records the work phase of an interrupted timer as abandoned
+// when it was last seen.
+func abandonTimer(timer *core.TimerState) error {
+	if timer.Cycle.Phase != core.PhaseWork || timer.Cycle.Waiting {
+		return nil
+	}
+	m := resumedPomoModel(timer)
+	timer.Resume(timer.Heartbeat)
+	return m.record(m.session(core.SessionAbandoned, timer.Heartbeat))
+}
+
+// run runs the timer until it is quit, then records an unfinished work
+// phase and forgets the saved state.
+func (m pomoModel) run(in io.Reader, out io.Writer) error {
+	final, err := tea.NewProgram(m, tea.WithInput(in), tea.WithOutput(out)).Run()
+	if err != nil {
+		return fmt.Errorf("error running program: %w", err)
+	}
+
+	m = final.(pomoModel)
+	m.pending.Wait()
+	if m.cycle.Phase == core.PhaseWork && !m.cycle.Waiting {
+		if err := m.record(m.session(core.SessionAbandoned, time.Now())); err != nil {
+			return err
+		}
+	}
+	if m.persist {
+		if err := vault.ClearTimer(); err != nil {
+			return fmt.Errorf("error clearing timer state: %w", err)
+		}
+	}
+	return nil
+}
+
+// pomoExec runs a pomodoro from the TUI, which hands over the terminal
+// while it runs.
+type pomoExec struct {
+	target pomoTarget
+	in     io.Reader
+	out    io.Writer
+}
+
+func (e *pomoExec) Run() error {
+	return runPomo(vault.CycleConfig(), &e.target, e.in, e.out)
+}
+func (e *pomoExec) SetStdin(r io.Reader)  { e.in = r }
+func (e *pomoExec) SetStdout(w io.Writer) { e.out = w }
+func (e *pomoExec) SetStderr(w io.Writer) {}
+
 const (
 	padding  = 2
 	maxWidth = 80
 )
 
-var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+var (
+	helpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	phaseStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF5F87")).Render
+	breakStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#5FD787")).Render
+)
 
 type tickMsg time.Time
 
+// recordedMsg reports that a finished work phase has been recorded.
+type recordedMsg struct{ err error }
+
 type pomoModel struct {
-	progress  progress.Model
-	start     time.Time
-	duration  time.Duration
-	elapsed   time.Duration
-	isPaused  bool
-	pauseTime time.Time
+	progress progress.Model
+	timer    *core.TimerState
+	cycle    *core.Cycle // the timer's
+	target   *pomoTarget
+	task     string          // the text of target, if any
+	persist  bool            // save the timer's state for --resume
+	lastTick time.Time       // the previous reading of the clock, to notice suspends
+	status   string          // feedback shown below the timer
+	pending  *sync.WaitGroup // sessions still being recorded
+}
+
+func initialPomoModel(config core.CycleConfig, target *pomoTarget) pomoModel {
+	timer := &core.TimerState{Cycle: *core.NewCycle(config), Category: pomoCategory}
+	if target != nil {
+		timer.TaskDate = target.date
+		timer.TaskLine = target.task.Line
+		timer.TaskLN = target.task.LineNumber
+	}
+	m := resumedPomoModel(timer)
+	m.startPhase()
+	return m
 }
 
-func initialPomoModel() pomoModel {
-	return pomoModel{
+// resumedPomoModel returns a model around a timer that has already started.
+func resumedPomoModel(timer *core.TimerState) pomoModel {
+	m := pomoModel{
 		progress: progress.New(
 			progress.WithoutPercentage(),
 			progress.WithDefaultGradient(),
 		),
-		duration: time.Duration(duration) * time.Minute,
-		start:    time.Now(),
-		isPaused: false,
+		timer:    timer,
+		cycle:    &timer.Cycle,
+		lastTick: time.Now(),
+		pending:  &sync.WaitGroup{},
 	}
+	if timer.TaskLine != "" {
+		if task, ok := core.ParseTask(timer.TaskLine, timer.TaskLN); ok {
+			m.target = &pomoTarget{date: timer.TaskDate, task: task}
+			m.task = task.FocusText()
+		}
+	}
+	return m
 }
 
 func (m pomoModel) Init() tea.Cmd {
-        // core.PlayMusic()
+	// core.PlayMusic()
 	return tickCmd()
 }
 
@@ -72,18 +293,38 @@ func (m pomoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "q":
+		case "q", "ctrl+c":
+			if m.timer.IsPaused() {
+				m.resume()
+			}
 			return m, tea.Quit
 		case "p", " ":
-			if m.isPaused {
-				m.elapsed += time.Since(m.pauseTime)
-				m.isPaused = false
-				return m, tickCmd()
-			} else {
-				m.isPaused = true
-				m.pauseTime = time.Now()
+			if m.cycle.Waiting {
 				return m, nil
 			}
+			if m.timer.IsPaused() {
+				m.resume()
+			} else {
+				m.timer.Pause(time.Now())
+				m.save()
+			}
+			return m, nil
+		case "enter":
+			if m.cycle.Waiting {
+				m.cycle.Start()
+				m.startPhase()
+				return m, m.progress.SetPercent(0)
+			}
+		case "s":
+			if m.cycle.Phase != core.PhaseWork {
+				m.cycle.Skip()
+				if m.cycle.Waiting {
+					m.save()
+				} else {
+					m.startPhase()
+				}
+				return m, m.progress.SetPercent(0)
+			}
 		}
 
 	case tea.WindowSizeMsg:
@@ -94,21 +335,36 @@ func (m pomoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tickMsg:
-		if m.isPaused {
-			return m, nil
+		// Ticks go on while paused or waiting, to keep the saved state
+		// fresh and notice suspends.
+		now := time.Now()
+		if slept := m.timer.AccountSuspend(m.lastTick, now); slept > 0 {
+			m.status = fmt.Sprintf("Asleep for %s, not counted", formatMinutes(slept))
+			m.save()
+		}
+		m.lastTick = now
+		if now.Sub(m.timer.Heartbeat) >= core.TimerHeartbeat {
+			m.save()
+		}
+		if m.timer.IsPaused() || m.cycle.Waiting {
+			return m, tickCmd()
 		}
 
-		elapsed := time.Since(m.start) - m.elapsed
-		if elapsed >= m.duration {
-			core.SendNotification(fmt.Sprintf("pomo session %dm done", duration), false)
-                        core.PauseMusic()
-			return m, tea.Quit
+		elapsed := m.timer.Elapsed(now)
+		if elapsed >= m.cycle.Duration() {
+			return m, tea.Batch(tickCmd(), m.finishPhase())
 		}
 
-		percentage := float64(elapsed) / float64(m.duration)
+		percentage := float64(elapsed) / float64(m.cycle.Duration())
 		progressCmd := m.progress.SetPercent(percentage)
 		return m, tea.Batch(tickCmd(), progressCmd)
 
+	case recordedMsg:
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+		}
+		return m, nil
+
 	case progress.FrameMsg:
 		progressModel, cmd := m.progress.Update(msg)
 		m.progress = progressModel.(progress.Model)
@@ -118,15 +374,136 @@ func (m pomoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m pomoModel) View() string {
-	var elapsed time.Duration
-	if m.isPaused {
-		elapsed = time.Since(m.start) - m.elapsed - time.Since(m.pauseTime)
+// startPhase starts timing the cycle's current phase from now.
+func (m *pomoModel) startPhase() {
+	now := time.Now()
+	m.timer.StartPhase(now)
+	m.lastTick = now
+	m.status = ""
+	m.save()
+}
+
+// save stores the timer's state for --resume, if it is to be kept.
+func (m *pomoModel) save() {
+	if !m.persist {
+		return
+	}
+	if err := vault.SaveTimer(m.timer); err != nil {
+		m.status = "Error saving timer: " + err.Error()
+	}
+}
+
+// finishPhase records a finished work phase, moves the cycle on and
+// notifies about it, starting the next phase right away with auto-start.
+func (m *pomoModel) finishPhase() tea.Cmd {
+	var cmds []tea.Cmd
+	if m.cycle.Phase == core.PhaseWork {
+		cmds = append(cmds, m.recordCmd(m.session(core.SessionCompleted, time.Now())))
+	}
+	ended := m.cycle.Finish()
+	message := phaseMessage(ended, m.cycle)
+
+	cmds = append(cmds, func() tea.Msg {
+		// Notifications are best effort, the timer goes on without them.
+		core.SendNotification(message, false)
+		return nil
+	})
+	m.status = ""
+	if m.cycle.Waiting {
+		m.save()
+		cmds = append(cmds, m.progress.SetPercent(1))
 	} else {
-		elapsed = time.Since(m.start) - m.elapsed
+		m.startPhase()
+		cmds = append(cmds, m.progress.SetPercent(0))
 	}
+	return tea.Batch(cmds...)
+}
 
-	remaining := m.duration - elapsed
+// recordCmd records a completed work phase and pauses the music outside of
+// Update, as waiting for the file locks would freeze the timer. The timer
+// waits for it before exiting.
+func (m pomoModel) recordCmd(s core.Session) tea.Cmd {
+	m.pending.Add(1)
+	return func() tea.Msg {
+		defer m.pending.Done()
+		err := m.record(s)
+		core.PauseMusic()
+		return recordedMsg{err}
+	}
+}
+
+// phaseMessage is the notification sent when the phase ended is over.
+func phaseMessage(ended string, cycle *core.Cycle) string {
+	next := formatMinutes(cycle.Duration())
+	switch {
+	case ended != core.PhaseWork:
+		return fmt.Sprintf("Break over, back to work for %s", next)
+	case cycle.Phase == core.PhaseLongBreak:
+		return fmt.Sprintf("Pomodoro %d done, that's a set! Take a %s long break", cycle.Completed, next)
+	default:
+		return fmt.Sprintf("Pomodoro %d done, take a %s break", cycle.Completed, next)
+	}
+}
+
+// resume ends the current pause.
+func (m *pomoModel) resume() {
+	now := time.Now()
+	m.timer.Resume(now)
+	m.lastTick = now
+	m.save()
+}
+
+// session is the current work phase, ending at end, as it is recorded in
+// the session log.
+func (m pomoModel) session(status string, end time.Time) core.Session {
+	planned := m.cycle.Duration()
+	actual := m.timer.Elapsed(end)
+	if status == core.SessionCompleted {
+		actual = planned
+	}
+	return core.Session{
+		Start:    m.timer.Start,
+		End:      end,
+		Duration: int(planned.Minutes()),
+		Actual:   int(actual.Seconds()),
+		Pauses:   m.timer.Pauses,
+		Status:   status,
+		Category: m.timer.Category,
+		Task:     m.task,
+	}
+}
+
+// record adds s to the session log and counts it against the target task.
+func (m pomoModel) record(s core.Session) error {
+	if err := vault.RecordSession(s); err != nil {
+		return fmt.Errorf("error recording session: %w", err)
+	}
+	if m.target != nil {
+		if err := vault.RecordFocus(m.target.date, m.target.task, s); err != nil {
+			return fmt.Errorf("error updating task: %w", err)
+		}
+	}
+	return nil
+}
+
+// phaseTitle names the current phase and where the cycle is in its set.
+func (m pomoModel) phaseTitle() string {
+	set, pomodoro := m.cycle.Position()
+	count := helpStyle(fmt.Sprintf("🍅 %d/%d · set %d", pomodoro, m.cycle.Config.LongBreakEvery, set))
+	switch m.cycle.Phase {
+	case core.PhaseShortBreak:
+		return breakStyle("Short break") + "  " + count
+	case core.PhaseLongBreak:
+		return breakStyle("Long break") + "  " + count
+	}
+	return phaseStyle("Work") + "  " + count
+}
+
+func (m pomoModel) View() string {
+	remaining := m.cycle.Duration() - m.timer.Elapsed(time.Now())
+	if m.cycle.Waiting {
+		remaining = m.cycle.Duration()
+	}
 	if remaining < 0 {
 		remaining = 0
 	}
@@ -136,15 +513,31 @@ func (m pomoModel) View() string {
 
 	pad := strings.Repeat(" ", padding)
 	status := ""
-	if m.isPaused {
+	if m.timer.IsPaused() {
 		status = "(Paused)"
+	} else if m.cycle.Waiting {
+		status = "(Press enter to start)"
 	}
 
-	return "\n" +
+	s := "\n" + pad + m.phaseTitle() + "\n"
+	if m.task != "" {
+		s += pad + m.task + "\n"
+	}
+	s += "\n" +
 		pad + fmt.Sprintf("%02d:%02d %s", minutes, seconds, status) + "\n" +
-		pad + m.progress.View() + "\n\n" +
-		pad + helpStyle("Press 'p' or space to pause/resume") + "\n" +
-		pad + helpStyle("Press 'q' to quit")
+		pad + m.progress.View() + "\n\n"
+	if m.status != "" {
+		s += pad + m.status + "\n\n"
+	}
+
+	help := "p/space: pause • q: quit"
+	if m.cycle.Waiting {
+		help = "enter: start • q: quit"
+	}
+	if m.cycle.Phase != core.PhaseWork {
+		help = strings.Replace(help, " • q", " • s: skip break • q", 1)
+	}
+	return s + pad + helpStyle(help)
 }
 
 func tickCmd() tea.Cmd {
@@ -152,4 +545,3 @@ func tickCmd() tea.Cmd {
 		return tickMsg(t)
 	})
 }
-
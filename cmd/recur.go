@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recurStart string
+	recurDate  string
+)
+
+var recurCmd = &cobra.Command{
+	Use:   "recur",
+	Short: "Manage recurring tasks",
+	Long: `Manage tasks that are added to every period they are due in.
+
+Rules are kept in recurring.toml in the vault root. Instances are added
+when a period file is created; "td recur apply" adds them to a file that
+already exists. A period never gets a second copy of a task with the same
+text, so a carried-over instance counts as this period's one.
+
+Rules:
+  every day, every weekday, every monday, every tue,thu
+  monthly on the 1st, monthly on the 1st and 15th, monthly on the last
+  every 2 weeks, every 3 days after completion`,
+}
+
+var recurAddCmd = &cobra.Command{
+	Use:   "add <text> <rule...>",
+	Short: "Add a recurring task",
+	Example: `  td recur add "Standup" every weekday
+  td recur add "Timesheet" monthly on the last
+  td recur add "Water plants" every 3 days after completion`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		start, err := parseDate(recurStart)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		task, err := vault.AddRecurring(args[0], strings.Join(args[1:], " "), start)
+		if err != nil {
+			fmt.Println("Error adding recurring task:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added recurring task %d: %s (%s)\n", task.ID, task.Text, task.Rule)
+	},
+}
+
+var recurListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recurring tasks",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks, err := vault.RecurringTasks()
+		if err != nil {
+			fmt.Println("Error listing recurring tasks:", err)
+			os.Exit(1)
+		}
+		for _, task := range tasks {
+			fmt.Printf("%d  %s  (%s, from %s)\n", task.ID, task.Text, task.Rule, task.Start)
+		}
+	},
+}
+
+var recurRmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Remove a recurring task",
+	Long:  "Remove a recurring task. Instances already in period files are kept.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Printf("Error: invalid id %q\n", args[0])
+			os.Exit(1)
+		}
+		task, err := vault.RemoveRecurring(id)
+		if err != nil {
+			fmt.Println("Error removing recurring task:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Removed recurring task:", task.Text)
+	},
+}
+
+var recurApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Add due recurring tasks to an existing period",
+	Long: `Add the recurring tasks due in a period whose file already exists, for
+example after adding a rule. Tasks already in the file are not added again.`,
+	Example: `  td recur apply
+  td recur apply --date tomorrow`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		date, err := parseDate(recurDate)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		added, err := vault.ApplyRecurring(date)
+		if err != nil {
+			fmt.Println("Error adding recurring tasks:", err)
+			os.Exit(1)
+		}
+		for _, task := range added {
+			fmt.Println("Added:", task.Text)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recurCmd)
+	recurCmd.AddCommand(recurAddCmd, recurListCmd, recurRmCmd, recurApplyCmd)
+	recurAddCmd.Flags().StringVar(&recurStart, "start", "today", "First day the task can be due "+dateFlagHelp)
+	recurApplyCmd.Flags().StringVar(&recurDate, "date", "today", "Period to add the tasks to "+dateFlagHelp)
+}
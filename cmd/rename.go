@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var renameTo string
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <id|text> --to <new text>",
+	Short: "Change the text of a task",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		date, task := resolveTask(args, core.TaskFilter{})
+		if err := vault.RenameTask(task.Address(date), renameTo); err != nil {
+			fmt.Println("Error renaming task:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Renamed: %s -> %s\n", task.Text, renameTo)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+	addTaskDateFlag(renameCmd)
+	renameCmd.Flags().StringVar(&renameTo, "to", "", "New text of the task")
+	renameCmd.MarkFlagRequired("to")
+}
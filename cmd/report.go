@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"td/core"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFrom   string
+	reportTo     string
+	reportOldest int
+	reportFormat string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show how tasks were got through",
+	Long: `Show, for every period from --from to --to, how many tasks were created,
+completed and carried over from an earlier period, the tasks that have been
+open the longest, and streaks of periods with every task done.
+
+A task is followed across periods by its text, without the carried:, from:,
+🍅 and focus: annotations. The same overview, with pomodoro stats, is the
+dashboard the TUI shows on D.`,
+	Example: `  td report
+  td report --from "start of month" -n 5
+  td report --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := parseDate(reportFrom)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		to, err := parseDate(reportTo)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+
+		today := time.Now()
+		report, err := vault.Report(from, to, today, reportOldest)
+		if err != nil {
+			fmt.Println("Error building report:", err)
+			os.Exit(1)
+		}
+		switch reportFormat {
+		case "text":
+			writeReport(os.Stdout, report, from, to, today)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(newReportJSON(report, today)); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		default:
+			fmt.Printf("Error: unknown format %q: expected text or json\n", reportFormat)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVar(&reportFrom, "from", "-4w", "Start of the range "+dateFlagHelp)
+	reportCmd.Flags().StringVar(&reportTo, "to", "today", "End of the range "+dateFlagHelp)
+	reportCmd.Flags().IntVarP(&reportOldest, "oldest", "n", 10, "How many of the longest open tasks to list")
+	reportCmd.Flags().StringVarP(&reportFormat, "format", "f", "text", "Output format: text or json")
+}
+
+// streakUnit names the periods of the vault's layout.
+func streakUnit(n int) string {
+	unit := map[string]string{"daily": "day", "weekly": "week", "monthly": "month"}[vault.Config().IntervalMode]
+	if n != 1 {
+		unit += "s"
+	}
+	return unit
+}
+
+func writeReport(w io.Writer, report core.Report, from, to, today time.Time) {
+	fmt.Fprintln(w, statsTitleStyle(fmt.Sprintf("Task report %s to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Created    %d\n", report.Created)
+	fmt.Fprintf(w, "  Completed  %d\n", report.Completed)
+	fmt.Fprintf(w, "  Carried    %d\n", report.CarriedIn)
+	fmt.Fprintf(w, "  Streak     %d %s with everything done (longest %d)\n",
+		report.CurrentStreak, streakUnit(report.CurrentStreak), report.LongestStreak)
+
+	if len(report.Periods) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, statsTitleStyle("Per period"))
+		for _, period := range report.Periods {
+			total := period.Completed + period.Open
+			fmt.Fprintf(w, "  %s  %s %3d created %3d done %3d carried %3d open\n",
+				period.Date.Format("2006-01-02 Mon"), doneBar(period.Completed, total),
+				period.Created, period.Completed, period.CarriedIn, period.Open)
+		}
+	}
+
+	if len(report.Oldest) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, statsTitleStyle("Open the longest"))
+		for _, open := range report.Oldest {
+			periods := "1 period"
+			if open.Periods != 1 {
+				periods = fmt.Sprintf("%d periods", open.Periods)
+			}
+			fmt.Fprintf(w, "  %4dd  %s %s\n", open.Age(today), open.Task.FocusText(),
+				helpStyle(fmt.Sprintf("(since %s, in %s)", open.Since.Format("2006-01-02"), periods)))
+		}
+	}
+}
+
+// doneBar is a short bar of the share of done tasks.
+func doneBar(done, total int) string {
+	const width = 10
+	filled := 0
+	if total > 0 {
+		filled = done * width / total
+	}
+	return barStyle(strings.Repeat("█", filled)) + helpStyle(strings.Repeat("░", width-filled))
+}
+
+// reportJSON is the json representation of a report.
+type reportJSON struct {
+	Created       int                `json:"created"`
+	Completed     int                `json:"completed"`
+	CarriedIn     int                `json:"carried"`
+	CurrentStreak int                `json:"current_streak"`
+	LongestStreak int                `json:"longest_streak"`
+	Periods       []periodReportJSON `json:"periods"`
+	Oldest        []openTaskJSON     `json:"oldest_open"`
+}
+
+type periodReportJSON struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	CarriedIn int    `json:"carried"`
+	Open      int    `json:"open"`
+}
+
+type openTaskJSON struct {
+	Text    string `json:"text"`
+	Date    string `json:"date"`
+	Since   string `json:"since"`
+	AgeDays int    `json:"age_days"`
+	Periods int    `json:"periods"`
+}
+
+func newReportJSON(report core.Report, today time.Time) reportJSON {
+	out := reportJSON{
+		Created:       report.Created,
+		Completed:     report.Completed,
+		CarriedIn:     report.CarriedIn,
+		CurrentStreak: report.CurrentStreak,
+		LongestStreak: report.LongestStreak,
+		Periods:       []periodReportJSON{},
+		Oldest:        []openTaskJSON{},
+	}
+	for _, period := range report.Periods {
+		out.Periods = append(out.Periods, periodReportJSON{
+			Date:      period.Date.Format("2006-01-02"),
+			Created:   period.Created,
+			Completed: period.Completed,
+			CarriedIn: period.CarriedIn,
+			Open:      period.Open,
+		})
+	}
+	for _, open := range report.Oldest {
+		out.Oldest = append(out.Oldest, openTaskJSON{
+			Text:    open.Task.Text,
+			Date:    open.Date.Format("2006-01-02"),
+			Since:   open.Since.Format("2006-01-02"),
+			AgeDays: open.Age(today),
+			Periods: open.Periods,
+		})
+	}
+	return out
+}
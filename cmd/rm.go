@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <id|text>",
+	Short: "Remove a task and its subtasks",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		date, task := resolveTask(args, core.TaskFilter{})
+		if err := vault.RemoveTask(task.Address(date)); err != nil {
+			fmt.Println("Error removing task:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Removed:", strings.TrimSpace(task.String()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+	addTaskDateFlag(rmCmd)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rolloverDate     string
+	rolloverMove     bool
+	rolloverAnnotate bool
+)
+
+var rolloverCmd = &cobra.Command{
+	Use:   "rollover",
+	Short: "Carry unfinished tasks over from the previous period",
+	Long: `Carry the open tasks of the previous period into the period of --date.
+
+Completed tasks stay behind and subtasks keep their nesting. Tasks that are
+already in the new period are skipped, so running it twice is harmless.
+With --move the carried tasks are removed from the previous period.
+
+Set "rollover" to copy or move in the config to do this automatically
+whenever a new period file is created.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		date, err := parseDate(rolloverDate)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+
+		annotate := vault.Config().RolloverAnnotate
+		if cmd.Flags().Changed("annotate") {
+			annotate = rolloverAnnotate
+		}
+		carried, err := vault.Rollover(date, core.RolloverOptions{Move: rolloverMove, Annotate: annotate})
+		if err != nil {
+			fmt.Println("Error rolling over tasks:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Carried %d task(s) into %s\n", len(carried), date.Format("2006-01-02"))
+		for _, task := range carried {
+			fmt.Println(strings.TrimRight(task.String(), " "))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rolloverCmd)
+	rolloverCmd.Flags().StringVar(&rolloverDate, "date", "today", "Period to carry tasks into "+dateFlagHelp)
+	rolloverCmd.Flags().BoolVar(&rolloverMove, "move", false, "Remove carried tasks from the previous period")
+	rolloverCmd.Flags().BoolVar(&rolloverAnnotate, "annotate", false, "Mark carried tasks with carried:N from:DATE (default: the rollover_annotate setting)")
+}
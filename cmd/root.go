@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"td/core"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
 
@@ -26,7 +29,11 @@ Features:
 - 📆 Daily, weekly, and monthly view options
 - 🖥️ Clean and intuitive TUI for distraction-free productivity`,
 	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(initialModel())
+		m := initialModel(vault)
+		if m.watcher != nil {
+			defer m.watcher.Close()
+		}
+		p := tea.NewProgram(m)
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
 			os.Exit(1)
@@ -43,41 +50,314 @@ func Execute() {
 	}
 }
 
+// vault is the task vault shared by all commands, built once at startup.
+var vault *core.Vault
+
+// configFlags maps persistent flag names to the config keys they override.
+var configFlags = map[string]string{
+	"vault-loc":     "vault_loc",
+	"interval-mode": "interval_mode",
+	"template-path": "template_path",
+	"skip-weekend":  "skip_weekend",
+}
+
 func init() {
+	cobra.OnInitialize(initVault)
 	// Here you will define your flags and configuration settings.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().String("vault-loc", "", "Vault directory (overrides config and TD_VAULT_LOC)")
+	rootCmd.PersistentFlags().String("interval-mode", "", "Interval mode: daily, weekly or monthly")
+	rootCmd.PersistentFlags().String("template-path", "", "Template file, relative to the vault")
+	rootCmd.PersistentFlags().Bool("skip-weekend", false, "Skip weekends when navigating days")
+}
+
+// flagOverrides returns the config values set explicitly on the command line.
+func flagOverrides() map[string]string {
+	overrides := map[string]string{}
+	for flagName, key := range configFlags {
+		flag := rootCmd.PersistentFlags().Lookup(flagName)
+		if flag != nil && flag.Changed {
+			overrides[key] = flag.Value.String()
+		}
+	}
+	return overrides
+}
+
+func initVault() {
+	config, err := core.LoadConfig(flagOverrides())
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		os.Exit(1)
+	}
+	vault = core.NewVault(config)
+	core.SetDefaultVault(vault)
 }
 
 type model struct {
-	cursor int
-	tasks  []core.Task
-	date   time.Time
+	vault     *core.Vault
+	cursor    int
+	groups    []group         // the period files shown, one unless zoomed out
+	rows      []row           // visible tasks, in display order
+	collapsed map[string]bool // collapsed parents by collapseKey
+	date      time.Time
+	zoom      string // "" for a single period, or core.ZoomWeek / core.ZoomMonth
+	status    string // feedback shown below the list
+	watcher   *core.PeriodWatcher
+
+	input         textinput.Model
+	mode          inputMode
+	renaming      core.TaskAddress // the task the rename prompt was opened on
+	confirmDelete bool             // d was pressed once, waiting for the second d
+
+	results      []core.SearchResult // shown instead of the tasks while set
+	resultCursor int
+
+	dashboard string // the rendered dashboard, shown instead of the tasks while set
 }
 
-func initialModel() model {
-	tasks, _ := core.LoadLinesWithSelection(time.Now())
-	return model{
-		tasks: tasks,
-		date:  time.Now(),
+// group is the task tree of one period file.
+type group struct {
+	date  time.Time
+	tasks []*core.TaskNode
+}
+
+// row is a visible task together with the date of the period file it is in,
+// which is what mutations address it by.
+type row struct {
+	*core.TaskNode
+	date  time.Time
+	group int
+}
+
+var overdueStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Render
+
+// fileChangedMsg is sent when the file of a shown period changed on disk.
+type fileChangedMsg struct{}
+
+// pomoDoneMsg is sent when a pomodoro started from the TUI has ended.
+type pomoDoneMsg struct{ err error }
+
+// editorDoneMsg is sent when the editor started from the TUI has exited.
+type editorDoneMsg struct{ err error }
+
+// zoomLevels is the order the z key cycles through.
+var zoomLevels = []string{"", core.ZoomWeek, core.ZoomMonth}
+
+func initialModel(vault *core.Vault) model {
+	m := model{
+		vault:     vault,
+		collapsed: map[string]bool{},
+		date:      time.Now(),
+		input:     textinput.New(),
 	}
+	// Without a watcher the TUI still works, it just won't see outside changes.
+	m.watcher, _ = vault.NewPeriodWatcher()
+	m.Refresh()
+	m.watchGroups()
+	return m
 }
 
 func (m model) Save() {
 	// Implement save functionality if needed
 }
 
+// Refresh reloads the tasks of the shown periods, keeping the cursor on the
+// same task if it is still there.
 func (m *model) Refresh() {
-	tasks, _ := core.LoadLinesWithSelection(time.Now())
-	m.tasks = tasks
+	var current *row
+	if m.cursor < len(m.rows) {
+		current = &m.rows[m.cursor]
+	}
+
+	m.groups = m.loadGroups()
+	m.rows = m.visibleRows()
+
+	if current != nil {
+		if i := findRow(m.rows, *current); i >= 0 {
+			m.cursor = i
+		}
+	}
+	if m.cursor >= len(m.rows) && len(m.rows) > 0 {
+		m.cursor = len(m.rows) - 1
+	}
+}
+
+// loadGroups reads the shown period, or every period in the zoomed-out range.
+func (m model) loadGroups() []group {
+	if m.zoom == "" {
+		tasks, _ := m.vault.LoadTaskTree(m.date)
+		return []group{{date: m.date, tasks: tasks}}
+	}
+
+	from, to := core.ViewRange(m.zoom, m.date)
+	periods, _ := m.vault.ListTasks(from, to, core.TaskFilter{})
+	var groups []group
+	for _, date := range m.vault.Periods(from, to) {
+		g := group{date: date}
+		for _, period := range periods {
+			if period.Date.Equal(date) {
+				g.tasks = core.BuildTaskTree(period.Tasks)
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// findRow returns the row holding the task of old, preferring the match
+// closest to its old line number, or -1 if the task is gone.
+func findRow(rows []row, old row) int {
+	found, distance := -1, 0
+	for i, r := range rows {
+		if r.Task.Text != old.Task.Text || !r.date.Equal(old.date) {
+			continue
+		}
+		d := r.Task.LineNumber - old.Task.LineNumber
+		if d < 0 {
+			d = -d
+		}
+		if found < 0 || d < distance {
+			found, distance = i, d
+		}
+	}
+	return found
+}
+
+// changeDate shows another period and points the watcher at its files.
+func (m *model) changeDate(date time.Time) {
+	m.date = date
+	m.cursor = 0
+	m.rows = nil
+	m.Refresh()
+	m.watchGroups()
+}
+
+// shiftDate moves one period, or one week or month when zoomed out.
+func (m *model) shiftDate(delta int) {
+	switch {
+	case m.zoom != "":
+		m.changeDate(core.ShiftView(m.zoom, m.date, delta))
+	case delta < 0:
+		m.changeDate(m.vault.PreviousDate(m.date))
+	default:
+		m.changeDate(m.vault.NextDate(m.date))
+	}
+}
+
+// cycleZoom switches between the single period, week and month views.
+func (m *model) cycleZoom() {
+	for i, zoom := range zoomLevels {
+		if zoom == m.zoom {
+			m.zoom = zoomLevels[(i+1)%len(zoomLevels)]
+			break
+		}
+	}
+	m.changeDate(m.date)
+	if m.zoom == "" {
+		m.status = "Showing a single period"
+	} else {
+		m.status = "Showing the whole " + m.zoom
+	}
+}
+
+func (m *model) watchGroups() {
+	if m.watcher == nil {
+		return
+	}
+	var dates []time.Time
+	for _, g := range m.groups {
+		dates = append(dates, g.date)
+	}
+	m.watcher.Watch(dates...)
+}
+
+// waitForChange delivers a fileChangedMsg on the next change to a watched file.
+func waitForChange(watcher *core.PeriodWatcher) tea.Cmd {
+	if watcher == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-watcher.Changes(); !ok {
+			return nil
+		}
+		return fileChangedMsg{}
+	}
+}
+
+func collapseKey(date time.Time, task core.Task) string {
+	return fmt.Sprintf("%s:%d", date.Format("2006-01-02"), task.LineNumber)
+}
+
+// visibleRows flattens the task trees, skipping subtasks of collapsed parents.
+func (m model) visibleRows() []row {
+	var rows []row
+	for i, g := range m.groups {
+		var walk func(nodes []*core.TaskNode)
+		walk = func(nodes []*core.TaskNode) {
+			for _, node := range nodes {
+				rows = append(rows, row{TaskNode: node, date: g.date, group: i})
+				if !m.collapsed[collapseKey(g.date, node.Task)] {
+					walk(node.Children)
+				}
+			}
+		}
+		walk(g.tasks)
+	}
+	return rows
+}
+
+// addDate is the period new tasks go to: that of the task under the cursor.
+func (m model) addDate() time.Time {
+	if m.cursor < len(m.rows) {
+		return m.rows[m.cursor].date
+	}
+	return m.date
+}
+
+// setStatus reports the outcome of a mutation on the status line. A conflict
+// means the file changed underneath us; the following Refresh reloads it.
+func (m *model) setStatus(err error) {
+	if errors.Is(err, core.ErrConflict) {
+		m.status = "File changed on disk, reloaded: " + err.Error()
+	} else if err != nil {
+		m.status = "Error: " + err.Error()
+	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return waitForChange(m.watcher)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case fileChangedMsg:
+		a := &m
+		a.Refresh()
+		return m, waitForChange(m.watcher)
+	case pomoDoneMsg:
+		a := &m
+		a.setStatus(msg.err)
+		a.Refresh()
+		return m, nil
+	case editorDoneMsg:
+		a := &m
+		a.setStatus(msg.err)
+		a.Refresh()
+		return m, nil
 	case tea.KeyMsg:
+		if m.mode != inputNone {
+			return m.updateInput(msg)
+		}
+		if m.results != nil {
+			return m.updateResults(msg)
+		}
+		if m.dashboard != "" {
+			return m.updateDashboard(msg)
+		}
+		m.status = ""
+		confirmDelete := m.confirmDelete
+		m.confirmDelete = false
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -86,57 +366,207 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 		case "left", "h":
-			m.date = core.PreviousDate(m.date)
 			a := &m
-			a.Refresh()
+			a.shiftDate(-1)
 		case "right", "l":
-			m.date = core.NextDate(m.date)
 			a := &m
-			a.Refresh()
+			a.shiftDate(1)
 		case "down", "j":
-			if m.cursor < len(m.tasks)-1 {
+			if m.cursor < len(m.rows)-1 {
 				m.cursor++
 			}
+		case "z":
+			a := &m
+			a.cycleZoom()
+		case "a":
+			return m, m.startInput(inputAdd, "")
+		case "g":
+			return m, m.startInput(inputGoto, "")
+		case "/":
+			return m, m.startInput(inputSearch, "")
+		case "D":
+			a := &m
+			a.openDashboard()
+			return m, nil
+		}
+
+		if len(m.rows) == 0 {
+			return m, nil
+		}
+		r := m.rows[m.cursor]
+		task := r.Task
+
+		switch msg.String() {
 		case "e":
-			lineNumber, _ := core.ContainsLine(m.date, m.tasks[m.cursor].Line)
-			core.OpenEditor(m.date, lineNumber, false) // Add false as the third argument
+			editor := &editorExec{vault: m.vault, date: r.date, line: task.LineNumber}
+			return m, tea.Exec(editor, func(err error) tea.Msg { return editorDoneMsg{err} })
+		case "enter", " ":
+			err := m.vault.UpdateTaskStatus(task.Address(r.date), !task.Selected)
 			a := &m
+			a.setStatus(err)
 			a.Refresh()
-		case "enter", " ":
-			selected := m.tasks[m.cursor].Selected
-			if selected {
-				m.tasks[m.cursor].Selected = false
-				core.UpdateTaskStatus(false, m.tasks[m.cursor].Line, m.date)
-			} else {
-				m.tasks[m.cursor].Selected = true
-				core.UpdateTaskStatus(true, m.tasks[m.cursor].Line, m.date)
+		case "x":
+			err := m.vault.UpdateTaskStatusCascade(task.Address(r.date), !task.Selected)
+			a := &m
+			a.setStatus(err)
+			a.Refresh()
+		case "c":
+			if len(r.Children) > 0 {
+				key := collapseKey(r.date, task)
+				m.collapsed[key] = !m.collapsed[key]
+				m.rows = m.visibleRows()
 			}
+		case "r":
+			// The rows may be reloaded while the prompt is open.
+			m.renaming = task.Address(r.date)
+			return m, m.startInput(inputRename, task.Text)
+		case "p":
+			pomo := &pomoExec{target: pomoTarget{date: r.date, task: task}}
+			return m, tea.Exec(pomo, func(err error) tea.Msg { return pomoDoneMsg{err} })
+		case "d":
+			a := &m
+			a.deleteTask(r, confirmDelete)
+		default:
+			a := &m
+			a.editTask(msg.String(), r)
+		}
+	default:
+		if m.mode != inputNone {
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
 		}
 	}
 	return m, nil
 }
 
-func (m model) View() string {
-	s := core.GetHeader(m.date)
+// focusSummary shows the pomodoros and focus time counted against a task.
+func focusSummary(task core.Task) string {
+	var parts []string
+	if task.Pomodoros > 0 {
+		parts = append(parts, fmt.Sprintf("🍅%d", task.Pomodoros))
+	}
+	if task.Focus > 0 {
+		parts = append(parts, formatMinutes(task.Focus))
+	}
+	return strings.Join(parts, " ")
+}
 
-	for i, task := range m.tasks {
-		cursor := " "
-		if m.cursor == i {
-			cursor = ">"
+// formatMinutes shows a duration as 45m or 1h30m.
+func formatMinutes(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// header is the title above the list: the period header, or the range and
+// storage period of each group when zoomed out.
+func (m model) header() string {
+	if m.zoom == "" {
+		return m.vault.GetHeader(m.date)
+	}
+	from, to := core.ViewRange(m.zoom, m.date)
+	if m.zoom == core.ZoomMonth {
+		return from.Format("January 2006") + "\n\n"
+	}
+	_, week := from.ISOWeek()
+	return fmt.Sprintf("Week %d: %s – %s\n\n", week, from.Format("Jan 2"), to.Format("Jan 2"))
+}
+
+// groupTitle labels a group by its period, with how many of its tasks are done.
+func (m model) groupTitle(g group) string {
+	done, total := 0, 0
+	var count func(nodes []*core.TaskNode)
+	count = func(nodes []*core.TaskNode) {
+		for _, node := range nodes {
+			total++
+			if node.Task.Selected {
+				done++
+			}
+			count(node.Children)
 		}
+	}
+	count(g.tasks)
+
+	label := strings.TrimSpace(m.vault.GetHeader(g.date))
+	if m.vault.Config().IntervalMode == "monthly" {
+		label = g.date.Format("January 2006")
+	}
+	if total == 0 {
+		return label + helpStyle("  no tasks")
+	}
+	return label + helpStyle(fmt.Sprintf("  %d/%d", done, total))
+}
 
-		checked := " "
-		if m.tasks[i].Selected {
-			checked = "x"
+func (m model) View() string {
+	if m.results != nil {
+		return m.resultsView()
+	}
+	if m.dashboard != "" {
+		return m.dashboardView()
+	}
+	s := m.header()
+
+	i := 0
+	for gi, g := range m.groups {
+		if m.zoom != "" {
+			if gi > 0 {
+				s += "\n"
+			}
+			s += m.groupTitle(g) + "\n"
 		}
+		for ; i < len(m.rows) && m.rows[i].group == gi; i++ {
+			s += m.rowView(i)
+		}
+	}
 
-		replaced := strings.ReplaceAll(task.Line, "- [ ]", "")
-		replaced = strings.ReplaceAll(replaced, "- [x]", "")
-		s += fmt.Sprintf("%s [%s] %s\n", cursor, checked, replaced)
+	if m.mode != inputNone {
+		s += "\n" + m.input.View() + "\n"
+	} else if m.status != "" {
+		s += "\n" + m.status + "\n"
 	}
 
 	// Use the existing helpStyle from pomo.go
-	s += "\n" + helpStyle("Press q to quit.")
+	s += "\n" + helpStyle("space: toggle • x: toggle with subtasks • c: collapse/expand • e: edit • p: pomodoro • z: zoom • g: go to date • /: search • D: dashboard • q: quit")
+	s += "\n" + helpStyle("a: add • r: rename • dd: delete • J/K: move down/up • tab/shift+tab: indent/outdent • n: move to next period")
 
 	return s
 }
+
+func (m model) rowView(i int) string {
+	r := m.rows[i]
+	task := r.Task
+	cursor := " "
+	if m.cursor == i {
+		cursor = ">"
+	}
+
+	checked := " "
+	if task.Selected {
+		checked = "x"
+	}
+
+	fold := " "
+	progress := ""
+	if len(r.Children) > 0 {
+		fold = "▾"
+		if m.collapsed[collapseKey(r.date, task)] {
+			fold = "▸"
+		}
+		done, total := r.Progress()
+		progress = helpStyle(fmt.Sprintf(" %d/%d", done, total))
+	}
+
+	text := task.FocusText()
+	if task.Overdue(time.Now()) {
+		text = overdueStyle(text)
+	}
+	if task.Pomodoros > 0 || task.Focus > 0 {
+		progress += helpStyle(" " + focusSummary(task))
+	}
+
+	indent := strings.Repeat("  ", task.Depth)
+	return fmt.Sprintf("%s %s%s[%s] %s%s\n", cursor, indent, fold, checked, text, progress)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"td/core"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchRegex  bool
+	searchTags   []string
+	searchDone   bool
+	searchOpen   bool
+	searchFrom   string
+	searchTo     string
+	searchFormat string
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query...>",
+	Short: "Search the tasks of every period in the vault",
+	Long: `Search the tasks of every period file in the vault, oldest first.
+
+The query is matched as a case-insensitive substring of the task text, or
+as a regular expression with --regex or when written as /regex/. Words like
+#tag require a tag and is:done or is:open the status, as in the TUI search (/).
+With --regex a #word is part of the expression; use --tag for tags.
+
+Output formats are those of td list.`,
+	Example: `  td search dentist
+  td search "#work is:open review"
+  td search --regex "PR #[0-9]+" --from "-1m"`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query, err := core.ParseSearchQuery(strings.Join(args, " "), searchRegex)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if searchDone && searchOpen {
+			fmt.Println("Error: --done and --open are mutually exclusive")
+			os.Exit(1)
+		}
+		query.Tags = append(query.Tags, searchTags...)
+		if searchDone {
+			query.Status = "done"
+		} else if searchOpen {
+			query.Status = "open"
+		}
+		if searchFrom != "" {
+			if query.From, err = parseDate(searchFrom); err != nil {
+				fmt.Println("Error parsing date:", err)
+				os.Exit(1)
+			}
+		}
+		if searchTo != "" {
+			if query.To, err = parseDate(searchTo); err != nil {
+				fmt.Println("Error parsing date:", err)
+				os.Exit(1)
+			}
+		}
+
+		results, err := vault.Search(query)
+		if err != nil {
+			fmt.Println("Error searching tasks:", err)
+			os.Exit(1)
+		}
+		if err := writeTasks(os.Stdout, searchFormat, groupResults(results)); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().BoolVarP(&searchRegex, "regex", "e", false, "Treat the query as a regular expression")
+	searchCmd.Flags().StringSliceVar(&searchTags, "tag", nil, "Only show tasks with this tag (repeatable)")
+	searchCmd.Flags().BoolVar(&searchDone, "done", false, "Only show completed tasks")
+	searchCmd.Flags().BoolVar(&searchOpen, "open", false, "Only show open tasks")
+	searchCmd.Flags().StringVar(&searchFrom, "from", "", "Only search periods from this date "+dateFlagHelp)
+	searchCmd.Flags().StringVar(&searchTo, "to", "", "Only search periods up to this date")
+	searchCmd.Flags().StringVarP(&searchFormat, "format", "f", "plain", "Output format: plain, markdown, json or csv")
+}
+
+// groupResults groups search results by period file for writeTasks.
+func groupResults(results []core.SearchResult) []core.PeriodTasks {
+	var periods []core.PeriodTasks
+	for _, result := range results {
+		if n := len(periods); n == 0 || periods[n-1].Filename != result.Filename {
+			periods = append(periods, core.PeriodTasks{Date: result.Date, Filename: result.Filename})
+		}
+		periods[len(periods)-1].Tasks = append(periods[len(periods)-1].Tasks, result.Task)
+	}
+	return periods
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"td/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchResultsShown is how many search results fit on screen at once.
+const searchResultsShown = 15
+
+// search runs a query typed after / and shows its results, most recent
+// selected.
+func (m *model) search(value string) {
+	query, err := core.ParseSearchQuery(value, false)
+	if err != nil {
+		m.setStatus(err)
+		return
+	}
+	results, err := m.vault.Search(query)
+	if err != nil {
+		m.setStatus(err)
+		return
+	}
+	if len(results) == 0 {
+		m.status = "No tasks match " + value
+		return
+	}
+	m.results = results
+	m.resultCursor = len(results) - 1
+	m.status = fmt.Sprintf("%d tasks match %s", len(results), value)
+}
+
+// updateResults handles keys while search results are shown.
+func (m model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.results = nil
+		m.status = ""
+	case "up", "k":
+		if m.resultCursor > 0 {
+			m.resultCursor--
+		}
+	case "down", "j":
+		if m.resultCursor < len(m.results)-1 {
+			m.resultCursor++
+		}
+	case "enter":
+		a := &m
+		a.jumpTo(m.results[m.resultCursor])
+	case "/":
+		m.results = nil
+		return m, m.startInput(inputSearch, "")
+	}
+	return m, nil
+}
+
+// jumpTo shows the period of a search result with the cursor on its task.
+func (m *model) jumpTo(result core.SearchResult) {
+	m.results = nil
+	m.changeDate(result.Date)
+
+	found := -1
+	for i, r := range m.rows {
+		if r.Task.LineNumber != result.Task.LineNumber || r.Task.Text != result.Task.Text {
+			continue
+		}
+		if found < 0 || r.date.Equal(result.Date) {
+			found = i
+		}
+	}
+	if found < 0 {
+		m.status = "Found in " + vaultPath(result.Filename) + ", which is not in the current layout"
+		return
+	}
+	m.cursor = found
+	m.status = ""
+}
+
+func (m model) resultsView() string {
+	start := 0
+	if m.resultCursor >= searchResultsShown {
+		start = m.resultCursor - searchResultsShown + 1
+	}
+	end := start + searchResultsShown
+	if end > len(m.results) {
+		end = len(m.results)
+	}
+
+	var b strings.Builder
+	b.WriteString(m.status + "\n\n")
+	for i := start; i < end; i++ {
+		result := m.results[i]
+		cursor := " "
+		if i == m.resultCursor {
+			cursor = ">"
+		}
+		checked := " "
+		if result.Task.Selected {
+			checked = "x"
+		}
+		fmt.Fprintf(&b, "%s %s [%s] %s\n", cursor, helpStyle(result.Date.Format("2006-01-02")), checked, strings.TrimSpace(result.Task.Text))
+	}
+	b.WriteString("\n" + helpStyle("enter: go to task • j/k: move • /: new search • esc: back"))
+	return b.String()
+}
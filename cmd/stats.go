@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"td/core"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsFrom   string
+	statsTo     string
+	statsBy     string
+	statsFormat string
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show pomodoro statistics",
+	Long: `Show statistics of the pomodoro sessions recorded in the vault between
+--from and --to: focus time, completion rate, average session length and
+pauses, a bar chart of focus time per day, week or month (--by), a heatmap
+of focus per day, and the focus time per category and task.
+
+--format json prints the same numbers, in minutes, for use elsewhere.`,
+	Example: `  td stats
+  td stats --from "start of month" --by week
+  td stats --from -12w --format json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := parseDate(statsFrom)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+		to, err := parseDate(statsTo)
+		if err != nil {
+			fmt.Println("Error parsing date:", err)
+			os.Exit(1)
+		}
+
+		stats, err := vault.Stats(from, to, statsBy)
+		if err != nil {
+			fmt.Println("Error computing stats:", err)
+			os.Exit(1)
+		}
+		switch statsFormat {
+		case "text":
+			writeStats(os.Stdout, stats, statsBy)
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(newStatsJSON(stats)); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		default:
+			fmt.Printf("Error: unknown format %q: expected text or json\n", statsFormat)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().StringVar(&statsFrom, "from", "-4w", "Start of the range "+dateFlagHelp)
+	statsCmd.Flags().StringVar(&statsTo, "to", "today", "End of the range "+dateFlagHelp)
+	statsCmd.Flags().StringVar(&statsBy, "by", "day", "Bar chart per day, week or month")
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "text", "Output format: text or json")
+}
+
+const (
+	barWidth    = 30
+	maxStatsRow = 10 // categories and tasks listed
+)
+
+var (
+	statsTitleStyle = lipgloss.NewStyle().Bold(true).Render
+	barStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Render
+	// heatLevels are the heatmap colors, from no focus to the most.
+	heatLevels = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#3A3A3A")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#0E4429")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#006D32")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#26A641")),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#39D353")),
+	}
+)
+
+func writeStats(w io.Writer, stats core.Stats, by string) {
+	total := stats.Total
+	fmt.Fprintln(w, statsTitleStyle(fmt.Sprintf("Pomodoro stats %s to %s", stats.From.Format("2006-01-02"), stats.To.Format("2006-01-02"))))
+	fmt.Fprintln(w)
+	if total.Sessions == 0 {
+		fmt.Fprintln(w, "No sessions recorded in this range.")
+		return
+	}
+	fmt.Fprintf(w, "  Focus      %s in %d sessions (%s on average)\n", formatMinutes(total.Focus), total.Sessions, formatMinutes(total.AverageFocus()))
+	fmt.Fprintf(w, "  Completed  %d of %d (%.0f%%)\n", total.Completed, total.Sessions, total.CompletionRate()*100)
+	fmt.Fprintf(w, "  Pauses     %d (%s in total)\n", total.Pauses, formatMinutes(total.Paused))
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, statsTitleStyle("Focus per "+by))
+	var max time.Duration
+	for _, bucket := range stats.Buckets {
+		if bucket.Focus > max {
+			max = bucket.Focus
+		}
+	}
+	for _, bucket := range stats.Buckets {
+		fmt.Fprintf(w, "  %-15s %s %s\n", bucketLabel(bucket.Start, by), bar(bucket.Focus, max), formatMinutes(bucket.Focus))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, statsTitleStyle("Heatmap"))
+	fmt.Fprint(w, heatmap(stats.Days))
+
+	writeGroups(w, "By category", stats.Categories)
+	writeGroups(w, "By task", stats.Tasks)
+}
+
+func writeGroups(w io.Writer, title string, groups []core.StatsGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, statsTitleStyle(title))
+	width := 0
+	for i, group := range groups {
+		if i < maxStatsRow && len(group.Name) > width {
+			width = len(group.Name)
+		}
+	}
+	max := groups[0].Focus
+	for i, group := range groups {
+		if i == maxStatsRow {
+			fmt.Fprintf(w, "  … and %d more\n", len(groups)-maxStatsRow)
+			break
+		}
+		fmt.Fprintf(w, "  %-*s %s %s  %d sessions, %.0f%% completed\n",
+			width, group.Name, bar(group.Focus, max), formatMinutes(group.Focus), group.Sessions, group.CompletionRate()*100)
+	}
+}
+
+// bar is a bar of barWidth characters filled in proportion to value/max.
+func bar(value, max time.Duration) string {
+	filled := 0
+	if max > 0 {
+		filled = int(float64(value) / float64(max) * barWidth)
+	}
+	if value > 0 && filled == 0 {
+		filled = 1
+	}
+	return barStyle(strings.Repeat("█", filled)) + helpStyle(strings.Repeat("░", barWidth-filled))
+}
+
+func bucketLabel(start time.Time, by string) string {
+	switch by {
+	case core.ZoomWeek:
+		_, week := start.ISOWeek()
+		return fmt.Sprintf("W%02d %s", week, start.Format("Jan 02"))
+	case core.ZoomMonth:
+		return start.Format("Jan 2006")
+	}
+	return start.Format("2006-01-02 Mon")
+}
+
+// heatmap draws days as a grid with a column per week and a row per
+// weekday, shaded by focus time relative to the busiest day.
+func heatmap(days []core.StatsBucket) string {
+	if len(days) == 0 {
+		return ""
+	}
+	var max time.Duration
+	for _, day := range days {
+		if day.Focus > max {
+			max = day.Focus
+		}
+	}
+
+	// Pad the first week so that rows line up with weekdays.
+	lead := (int(days[0].Start.Weekday()) + 6) % 7
+	weeks := (lead + len(days) + 6) / 7
+
+	// Month names go above the week they start in, if there is room.
+	header := []byte(strings.Repeat(" ", weeks*2+3))
+	month, free := "", 0
+	for week := 0; week < weeks; week++ {
+		i := week*7 - lead
+		if i < 0 {
+			i = 0
+		}
+		if name := days[i].Start.Format("Jan"); name != month && week*2 >= free {
+			month, free = name, week*2+len(name)+1
+			copy(header[week*2:], name)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("      " + strings.TrimRight(string(header), " ") + "\n")
+
+	for weekday := 0; weekday < 7; weekday++ {
+		b.WriteString("  " + time.Weekday((weekday + 1) % 7).String()[:3] + " ")
+		for week := 0; week < weeks; week++ {
+			i := week*7 + weekday - lead
+			if i < 0 || i >= len(days) {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(heatLevels[heatLevel(days[i].Focus, max)].Render("■") + " ")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("      less ")
+	for _, level := range heatLevels {
+		b.WriteString(level.Render("■") + " ")
+	}
+	b.WriteString("more\n")
+	return b.String()
+}
+
+func heatLevel(focus, max time.Duration) int {
+	if focus <= 0 || max <= 0 {
+		return 0
+	}
+	level := int(float64(focus)/float64(max)*float64(len(heatLevels)-1) + 0.999)
+	if level >= len(heatLevels) {
+		level = len(heatLevels) - 1
+	}
+	return level
+}
+
+// statsJSON is the json representation of the stats, in minutes.
+type statsJSON struct {
+	From       string            `json:"from"`
+	To         string            `json:"to"`
+	Total      sessionStatsJSON  `json:"total"`
+	Buckets    []statsBucketJSON `json:"buckets"`
+	Days       []statsBucketJSON `json:"days"`
+	Categories []statsGroupJSON  `json:"categories"`
+	Tasks      []statsGroupJSON  `json:"tasks"`
+}
+
+type sessionStatsJSON struct {
+	Sessions       int     `json:"sessions"`
+	Completed      int     `json:"completed"`
+	CompletionRate float64 `json:"completion_rate"`
+	FocusMinutes   float64 `json:"focus_minutes"`
+	AverageMinutes float64 `json:"average_minutes"`
+	Pauses         int     `json:"pauses"`
+	PausedMinutes  float64 `json:"paused_minutes"`
+}
+
+type statsBucketJSON struct {
+	Start string `json:"start"`
+	sessionStatsJSON
+}
+
+type statsGroupJSON struct {
+	Name string `json:"name"`
+	sessionStatsJSON
+}
+
+func newSessionStatsJSON(s core.SessionStats) sessionStatsJSON {
+	return sessionStatsJSON{
+		Sessions:       s.Sessions,
+		Completed:      s.Completed,
+		CompletionRate: s.CompletionRate(),
+		FocusMinutes:   s.Focus.Minutes(),
+		AverageMinutes: s.AverageFocus().Minutes(),
+		Pauses:         s.Pauses,
+		PausedMinutes:  s.Paused.Minutes(),
+	}
+}
+
+func newStatsJSON(stats core.Stats) statsJSON {
+	out := statsJSON{
+		From:       stats.From.Format("2006-01-02"),
+		To:         stats.To.Format("2006-01-02"),
+		Total:      newSessionStatsJSON(stats.Total),
+		Buckets:    []statsBucketJSON{},
+		Days:       []statsBucketJSON{},
+		Categories: []statsGroupJSON{},
+		Tasks:      []statsGroupJSON{},
+	}
+	for _, bucket := range stats.Buckets {
+		out.Buckets = append(out.Buckets, statsBucketJSON{bucket.Start.Format("2006-01-02"), newSessionStatsJSON(bucket.SessionStats)})
+	}
+	for _, day := range stats.Days {
+		out.Days = append(out.Days, statsBucketJSON{day.Start.Format("2006-01-02"), newSessionStatsJSON(day.SessionStats)})
+	}
+	for _, group := range stats.Categories {
+		out.Categories = append(out.Categories, statsGroupJSON{group.Name, newSessionStatsJSON(group.SessionStats)})
+	}
+	for _, group := range stats.Tasks {
+		out.Tasks = append(out.Tasks, statsGroupJSON{group.Name, newSessionStatsJSON(group.SessionStats)})
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrConflict is returned when a mutation addresses a line that no longer
+// holds the task it was read from, because the file changed in between.
+var ErrConflict = errors.New("task changed on disk")
+
+// TaskAddress points at a task in the period file for Date. Hash is the hash
+// of the line when it was read and guards against editing a different line.
+type TaskAddress struct {
+	Date       time.Time
+	LineNumber int
+	Hash       string
+}
+
+func HashLine(line string) string {
+	sum := sha256.Sum256([]byte(line))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Address returns the address of a task read from the period file for date.
+func (t Task) Address(date time.Time) TaskAddress {
+	return TaskAddress{Date: date, LineNumber: t.LineNumber, Hash: HashLine(t.Line)}
+}
+
+func (a TaskAddress) String() string {
+	return fmt.Sprintf("%s:%d", a.Date.Format(taskDateLayout), a.LineNumber)
+}
+
+// resolve returns the index in lines of the task at a, or ErrConflict if the
+// line is missing or its content no longer matches.
+func (a TaskAddress) resolve(lines []string) (int, error) {
+	i := a.LineNumber - 1
+	if i < 0 || i >= len(lines) {
+		return 0, fmt.Errorf("%w: line %d no longer exists", ErrConflict, a.LineNumber)
+	}
+	if HashLine(lines[i]) != a.Hash {
+		return 0, fmt.Errorf("%w: line %d is now %q", ErrConflict, a.LineNumber, lines[i])
+	}
+	if _, ok := ParseTask(lines[i], a.LineNumber); !ok {
+		return 0, fmt.Errorf("line %d is not a task", a.LineNumber)
+	}
+	return i, nil
+}
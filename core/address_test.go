@@ -0,0 +1,54 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUpdateTaskStatusAddressesExactLine(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] review PR #42\n- [ ] review PR\n")
+
+	tasks, err := v.LoadLinesWithSelection(testDate)
+	if err != nil {
+		t.Fatalf("LoadLinesWithSelection() error = %v", err)
+	}
+	if err := v.UpdateTaskStatus(tasks[1].Address(testDate), true); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+
+	want := "- [ ] review PR #42\n- [x] review PR\n"
+	if got := readTestFile(t, filename); got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
+
+func TestUpdateTaskStatusConflict(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Task 1\n- [ ] Task 2\n")
+
+	tasks, err := v.LoadLinesWithSelection(testDate)
+	if err != nil {
+		t.Fatalf("LoadLinesWithSelection() error = %v", err)
+	}
+
+	// Another writer inserts a line above, shifting Task 2 down.
+	changed := "- [ ] New task\n- [ ] Task 1\n- [ ] Task 2\n"
+	writeTestFile(t, v, testDate, changed)
+
+	err = v.UpdateTaskStatus(tasks[1].Address(testDate), true)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("UpdateTaskStatus() error = %v, want ErrConflict", err)
+	}
+	if got := readTestFile(t, filename); got != changed {
+		t.Errorf("File content = %q, want it untouched", got)
+	}
+
+	err = v.UpdateTaskStatus(TaskAddress{Date: testDate, LineNumber: 10, Hash: tasks[0].Address(testDate).Hash}, true)
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("UpdateTaskStatus() past end error = %v, want ErrConflict", err)
+	}
+}
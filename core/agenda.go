@@ -0,0 +1,80 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// Overdue reports whether the task is open and was due before today.
+func (t Task) Overdue(today time.Time) bool {
+	return !t.Selected && !t.Due.IsZero() && t.Due.Before(truncateDay(today))
+}
+
+// AgendaItem is an open task that is due or scheduled on When.
+type AgendaItem struct {
+	Task     Task
+	Date     time.Time // the period the task is in
+	Filename string
+	When     time.Time
+	Kind     string // "due" or "scheduled"
+	Overdue  bool
+}
+
+// Agenda returns the open tasks in the whole vault that are due or scheduled
+// from today up to and including days days later, and those that are
+// overdue, ordered by date. A task that was carried over into later periods
+// is only looked at in the latest one, so a copy left behind does not show
+// up once the carried task is done.
+func (v *Vault) Agenda(today time.Time, days int) ([]AgendaItem, error) {
+	today = truncateDay(today)
+	last := today.AddDate(0, 0, days)
+
+	files, err := v.Index()
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]AgendaItem{}
+	for _, file := range files {
+		for _, task := range file.Tasks {
+			if task.Due.IsZero() && task.Scheduled.IsZero() {
+				continue
+			}
+			// Files are in date order, so later copies replace earlier ones.
+			latest[carryKey(task.Text)] = AgendaItem{Task: task, Date: file.Date, Filename: file.Filename}
+		}
+	}
+
+	var items []AgendaItem
+	for _, item := range latest {
+		task := item.Task
+		if task.Selected {
+			continue
+		}
+		switch {
+		case task.Overdue(today):
+			item.When, item.Kind, item.Overdue = task.Due, "due", true
+		case !task.Due.IsZero() && !task.Due.After(last):
+			item.When, item.Kind = task.Due, "due"
+		case !task.Scheduled.IsZero() && !task.Scheduled.After(last):
+			item.When, item.Kind = task.Scheduled, "scheduled"
+		default:
+			continue
+		}
+		if item.When.Before(today) && !item.Overdue {
+			// Scheduled in the past but not due: still on the agenda today.
+			item.When = today
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].When.Equal(items[j].When) {
+			return items[i].When.Before(items[j].When)
+		}
+		if !items[i].Date.Equal(items[j].Date) {
+			return items[i].Date.Before(items[j].Date)
+		}
+		return items[i].Task.LineNumber < items[j].Task.LineNumber
+	})
+	return items, nil
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgenda(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	today := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+
+	writeTestFile(t, v, today.AddDate(0, 0, -2), "- [ ] Report due:2024-08-29\n- [ ] Invoice due:2024-08-28\n- [ ] Later due:2024-09-20\n")
+	writeTestFile(t, v, today.AddDate(0, 0, -1), "- [x] Invoice due:2024-08-28 carried:1 from:2024-08-28\n")
+	writeTestFile(t, v, today, "- [ ] Call Bob scheduled:2024-09-01\n- [ ] Plan due:2024-09-06\n- [x] Done due:2024-08-31\n- [ ] Plain\n")
+
+	items, err := v.Agenda(today, 7)
+	if err != nil {
+		t.Fatalf("Agenda() error = %v", err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind+" "+item.When.Format(taskDateLayout)+" "+item.Task.Text)
+	}
+	want := []string{
+		"due 2024-08-29 Report due:2024-08-29",
+		"scheduled 2024-09-01 Call Bob scheduled:2024-09-01",
+		"due 2024-09-06 Plan due:2024-09-06",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Agenda() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Agenda()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if !items[0].Overdue || items[1].Overdue {
+		t.Errorf("Overdue = %v, %v; want true, false", items[0].Overdue, items[1].Overdue)
+	}
+}
+
+func TestTaskOverdue(t *testing.T) {
+	today := time.Date(2024, 8, 30, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"- [ ] Pay due:2024-08-29", true},
+		{"- [ ] Pay due:2024-08-30", false},
+		{"- [x] Pay due:2024-08-29", false},
+		{"- [ ] Pay scheduled:2024-08-01", false},
+	}
+	for _, tt := range tests {
+		task, _ := ParseTask(tt.line, 1)
+		if got := task.Overdue(today); got != tt.want {
+			t.Errorf("%q Overdue() = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
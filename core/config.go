@@ -0,0 +1,323 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the settings a Vault is built from.
+type Config struct {
+	VaultLoc     string `toml:"vault_loc"`
+	IntervalMode string `toml:"interval_mode"` //daily, weekly or monthly
+	TemplatePath string `toml:"template_path"`
+	SkipWeekend  bool   `toml:"skip_weekend"`
+	CopyPrevious bool   `toml:"copy_previous"`
+	// AutoCompleteParents checks a parent task once all its subtasks are done.
+	AutoCompleteParents bool `toml:"auto_complete_parents"`
+	// Rollover carries open tasks into a new period file when it is
+	// created: "off", "copy" or "move".
+	Rollover         string `toml:"rollover"`
+	RolloverAnnotate bool   `toml:"rollover_annotate"`
+	// Pomodoro cycle lengths in minutes, see CycleConfig.
+	PomoWork           int  `toml:"pomo_work"`
+	PomoShortBreak     int  `toml:"pomo_short_break"`
+	PomoLongBreak      int  `toml:"pomo_long_break"`
+	PomoLongBreakEvery int  `toml:"pomo_long_break_every"`
+	PomoAutoStart      bool `toml:"pomo_auto_start"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		VaultLoc:         ".td",
+		IntervalMode:     "weekly",
+		TemplatePath:     ".template",
+		Rollover:         "off",
+		RolloverAnnotate: true,
+
+		PomoWork:           25,
+		PomoShortBreak:     5,
+		PomoLongBreak:      15,
+		PomoLongBreakEvery: 4,
+	}
+}
+
+// configKey describes one setting: its name in the config file, the
+// environment variable overriding it and how to read and write it.
+type configKey struct {
+	name string
+	env  string
+	get  func(c *Config) interface{}
+	set  func(c *Config, value string) error
+}
+
+func stringKey(name, env string, field func(c *Config) *string) configKey {
+	return configKey{
+		name: name,
+		env:  env,
+		get:  func(c *Config) interface{} { return *field(c) },
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+func boolKey(name, env string, field func(c *Config) *bool) configKey {
+	return configKey{
+		name: name,
+		env:  env,
+		get:  func(c *Config) interface{} { return *field(c) },
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s: expected true or false", value, name)
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
+
+// intKey is a whole number setting of at least min.
+func intKey(name, env string, min int, field func(c *Config) *int) configKey {
+	return configKey{
+		name: name,
+		env:  env,
+		get:  func(c *Config) interface{} { return *field(c) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < min {
+				return fmt.Errorf("invalid value %q for %s: expected a whole number of at least %d", value, name, min)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+// choiceKey is a string setting restricted to the given values.
+func choiceKey(name, env string, field func(c *Config) *string, choices ...string) configKey {
+	return configKey{
+		name: name,
+		env:  env,
+		get:  func(c *Config) interface{} { return *field(c) },
+		set: func(c *Config, value string) error {
+			for _, choice := range choices {
+				if value == choice {
+					*field(c) = value
+					return nil
+				}
+			}
+			return fmt.Errorf("invalid value %q for %s: expected %s", value, name, strings.Join(choices, ", "))
+		},
+	}
+}
+
+var configKeys = []configKey{
+	stringKey("vault_loc", "TD_VAULT_LOC", func(c *Config) *string { return &c.VaultLoc }),
+	choiceKey("interval_mode", "TD_INTERVAL_MODE", func(c *Config) *string { return &c.IntervalMode }, "daily", "weekly", "monthly"),
+	stringKey("template_path", "TD_TEMPLATE_PATH", func(c *Config) *string { return &c.TemplatePath }),
+	boolKey("skip_weekend", "TD_SKIP_WEEKEND", func(c *Config) *bool { return &c.SkipWeekend }),
+	boolKey("copy_previous", "TD_COPY_PREVIOUS", func(c *Config) *bool { return &c.CopyPrevious }),
+	boolKey("auto_complete_parents", "TD_AUTO_COMPLETE_PARENTS", func(c *Config) *bool { return &c.AutoCompleteParents }),
+	choiceKey("rollover", "TD_ROLLOVER", func(c *Config) *string { return &c.Rollover }, "off", "copy", "move"),
+	boolKey("rollover_annotate", "TD_ROLLOVER_ANNOTATE", func(c *Config) *bool { return &c.RolloverAnnotate }),
+	intKey("pomo_work", "TD_POMO_WORK", 1, func(c *Config) *int { return &c.PomoWork }),
+	intKey("pomo_short_break", "TD_POMO_SHORT_BREAK", 0, func(c *Config) *int { return &c.PomoShortBreak }),
+	intKey("pomo_long_break", "TD_POMO_LONG_BREAK", 0, func(c *Config) *int { return &c.PomoLongBreak }),
+	intKey("pomo_long_break_every", "TD_POMO_LONG_BREAK_EVERY", 1, func(c *Config) *int { return &c.PomoLongBreakEvery }),
+	boolKey("pomo_auto_start", "TD_POMO_AUTO_START", func(c *Config) *bool { return &c.PomoAutoStart }),
+}
+
+func lookupConfigKey(name string) (configKey, error) {
+	for _, k := range configKeys {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	return configKey{}, fmt.Errorf("unknown config key %q", name)
+}
+
+// ConfigKeys returns the names of all settings in file order.
+func ConfigKeys() []string {
+	names := make([]string, len(configKeys))
+	for i, k := range configKeys {
+		names[i] = k.name
+	}
+	return names
+}
+
+func (c *Config) Get(name string) (string, error) {
+	k, err := lookupConfigKey(name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(k.get(c)), nil
+}
+
+func (c *Config) Set(name, value string) error {
+	k, err := lookupConfigKey(name)
+	if err != nil {
+		return err
+	}
+	return k.set(c, value)
+}
+
+func (c *Config) applyEnv() error {
+	for _, k := range configKeys {
+		if value, exists := os.LookupEnv(k.env); exists {
+			if err := k.set(c, value); err != nil {
+				return fmt.Errorf("%s: %w", k.env, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyOverrides(overrides map[string]string) error {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := c.Set(name, overrides[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) applyFile(path string) error {
+	if !fileExists(path) {
+		return nil
+	}
+	if _, err := toml.DecodeFile(path, c); err != nil {
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+	return nil
+}
+
+// UserConfigPath returns the per-user config file,
+// $XDG_CONFIG_HOME/td/config.toml or ~/.config/td/config.toml.
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "td", "config.toml")
+}
+
+// VaultConfigPath returns the shared config file kept in the vault root.
+func VaultConfigPath(vaultLoc string) string {
+	return filepath.Join(vaultLoc, "config.toml")
+}
+
+// xdgConfigPaths returns the system and user config files, lowest priority first.
+func xdgConfigPaths() []string {
+	var paths []string
+	dirs := os.Getenv("XDG_CONFIG_DIRS")
+	if dirs == "" {
+		dirs = "/etc/xdg"
+	}
+	list := strings.Split(dirs, ":")
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i] != "" {
+			paths = append(paths, filepath.Join(list[i], "td", "config.toml"))
+		}
+	}
+	if user := UserConfigPath(); user != "" {
+		paths = append(paths, user)
+	}
+	return paths
+}
+
+// ConfigFromEnv returns the defaults overridden by the TD_* environment
+// variables, or an error naming a variable with an invalid value.
+func ConfigFromEnv() (Config, error) {
+	c := DefaultConfig()
+	err := c.applyEnv()
+	return c, err
+}
+
+// LoadConfig merges defaults, config files, environment variables and the
+// given overrides (usually command line flags), in that order. The vault
+// config file is looked up in the vault location resolved from the other layers.
+func LoadConfig(overrides map[string]string) (Config, error) {
+	c := DefaultConfig()
+	for _, path := range xdgConfigPaths() {
+		if err := c.applyFile(path); err != nil {
+			return c, err
+		}
+	}
+
+	probe := c
+	if err := probe.applyEnv(); err != nil {
+		return c, err
+	}
+	if err := probe.applyOverrides(overrides); err != nil {
+		return c, err
+	}
+	if err := c.applyFile(VaultConfigPath(probe.VaultLoc)); err != nil {
+		return c, err
+	}
+
+	if err := c.applyEnv(); err != nil {
+		return c, err
+	}
+	if err := c.applyOverrides(overrides); err != nil {
+		return c, err
+	}
+	return c, c.validate()
+}
+
+// validate re-checks values that came from config files, which bypass Set.
+func (c *Config) validate() error {
+	for _, k := range configKeys {
+		if err := k.set(c, fmt.Sprint(k.get(c))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConfigValue validates and stores a single setting in the config file at path.
+func SetConfigValue(path, name, value string) error {
+	k, err := lookupConfigKey(name)
+	if err != nil {
+		return err
+	}
+	var c Config
+	if err := k.set(&c, value); err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	if fileExists(path) {
+		if _, err := toml.DecodeFile(path, &values); err != nil {
+			return fmt.Errorf("error reading config %s: %w", path, err)
+		}
+	}
+	values[name] = k.get(&c)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// Encode in memory first so that a failure leaves the file untouched.
+	var out bytes.Buffer
+	if err := toml.NewEncoder(&out).Encode(values); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out.Bytes())
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+// clearConfigEnv unsets the TD_* variables for the duration of the test.
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, k := range configKeys {
+		t.Setenv(k.env, "")
+		os.Unsetenv(k.env)
+	}
+}
+
+func TestLoadConfigLayering(t *testing.T) {
+	tempDir := t.TempDir()
+	vaultDir := filepath.Join(tempDir, "vault")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "xdg"))
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(tempDir, "system"))
+	clearConfigEnv(t)
+
+	writeConfigFile(t, filepath.Join(tempDir, "system", "td", "config.toml"), "template_path = \"system.md\"\nskip_weekend = true\n")
+	writeConfigFile(t, filepath.Join(tempDir, "xdg", "td", "config.toml"), "vault_loc = \""+vaultDir+"\"\ninterval_mode = \"daily\"\n")
+	writeConfigFile(t, filepath.Join(vaultDir, "config.toml"), "interval_mode = \"monthly\"\ncopy_previous = true\n")
+
+	config, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := DefaultConfig()
+	want.VaultLoc = vaultDir
+	want.IntervalMode = "monthly"
+	want.TemplatePath = "system.md"
+	want.SkipWeekend = true
+	want.CopyPrevious = true
+	if config != want {
+		t.Errorf("LoadConfig() = %+v, want %+v", config, want)
+	}
+
+	t.Setenv("TD_INTERVAL_MODE", "weekly")
+	t.Setenv("TD_SKIP_WEEKEND", "false")
+	config, err = LoadConfig(map[string]string{"skip_weekend": "true"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.IntervalMode != "weekly" {
+		t.Errorf("IntervalMode = %v, want env value weekly", config.IntervalMode)
+	}
+	if !config.SkipWeekend {
+		t.Errorf("SkipWeekend = false, want flag value true")
+	}
+}
+
+func TestLoadConfigRejectsInvalidFileValue(t *testing.T) {
+	tempDir := t.TempDir()
+	clearConfigEnv(t)
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(tempDir, "none"))
+	t.Setenv("TD_VAULT_LOC", filepath.Join(tempDir, "vault"))
+
+	writeConfigFile(t, filepath.Join(tempDir, "td", "config.toml"), "interval_mode = \"hourly\"\n")
+	if _, err := LoadConfig(nil); err == nil {
+		t.Errorf("LoadConfig() error = nil, want error for invalid interval_mode")
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "td", "config.toml")
+
+	if err := SetConfigValue(path, "interval_mode", "daily"); err != nil {
+		t.Fatalf("SetConfigValue() error = %v", err)
+	}
+	if err := SetConfigValue(path, "skip_weekend", "true"); err != nil {
+		t.Fatalf("SetConfigValue() error = %v", err)
+	}
+	if err := SetConfigValue(path, "skip_weekend", "maybe"); err == nil {
+		t.Errorf("SetConfigValue() with invalid bool error = nil")
+	}
+	if err := SetConfigValue(path, "no_such_key", "1"); err == nil {
+		t.Errorf("SetConfigValue() with unknown key error = nil")
+	}
+
+	config := DefaultConfig()
+	if err := config.applyFile(path); err != nil {
+		t.Fatalf("applyFile() error = %v", err)
+	}
+	if config.IntervalMode != "daily" || !config.SkipWeekend {
+		t.Errorf("config after set = %+v", config)
+	}
+}
+
+func TestConfigFromEnvRejectsInvalidValue(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("TD_ROLLOVER", "foo")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Errorf("ConfigFromEnv() with TD_ROLLOVER=foo error = nil")
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import "time"
+
+// Pomodoro phases.
+const (
+	PhaseWork       = "work"
+	PhaseShortBreak = "short break"
+	PhaseLongBreak  = "long break"
+)
+
+// CycleConfig sets the lengths of the phases of a pomodoro cycle.
+type CycleConfig struct {
+	Work           time.Duration `json:"work"`
+	ShortBreak     time.Duration `json:"short_break"`
+	LongBreak      time.Duration `json:"long_break"`
+	LongBreakEvery int           `json:"long_break_every"` // work phases before a long break
+	AutoStart      bool          `json:"auto_start"`       // start the next phase without waiting for the user
+}
+
+// CycleConfig returns the pomodoro cycle set up in the vault's config.
+func (v *Vault) CycleConfig() CycleConfig {
+	return CycleConfig{
+		Work:           time.Duration(v.config.PomoWork) * time.Minute,
+		ShortBreak:     time.Duration(v.config.PomoShortBreak) * time.Minute,
+		LongBreak:      time.Duration(v.config.PomoLongBreak) * time.Minute,
+		LongBreakEvery: v.config.PomoLongBreakEvery,
+		AutoStart:      v.config.PomoAutoStart,
+	}
+}
+
+// Cycle is the state machine of a pomodoro timer: work phases alternate
+// with short breaks, and every LongBreakEvery work phases the break is a
+// long one. Timing is left to the caller, which calls Finish when the
+// current phase has run for Duration.
+type Cycle struct {
+	Config    CycleConfig `json:"config"`
+	Phase     string      `json:"phase"`     // PhaseWork, PhaseShortBreak or PhaseLongBreak
+	Completed int         `json:"completed"` // work phases finished so far
+	// Waiting is set when a phase has ended and, without AutoStart, the
+	// next one waits for Start.
+	Waiting bool `json:"waiting"`
+}
+
+// NewCycle returns a cycle starting with a work phase.
+func NewCycle(config CycleConfig) *Cycle {
+	config.normalize()
+	return &Cycle{Config: config, Phase: PhaseWork}
+}
+
+func (c *CycleConfig) normalize() {
+	if c.LongBreakEvery < 1 {
+		c.LongBreakEvery = 1
+	}
+}
+
+// Duration returns how long the current phase lasts.
+func (c *Cycle) Duration() time.Duration {
+	switch c.Phase {
+	case PhaseShortBreak:
+		return c.Config.ShortBreak
+	case PhaseLongBreak:
+		return c.Config.LongBreak
+	}
+	return c.Config.Work
+}
+
+// Finish ends the current phase and moves on to the next one, returning
+// the phase that ended.
+func (c *Cycle) Finish() string {
+	ended := c.Phase
+	c.Phase = c.next()
+	if ended == PhaseWork {
+		c.Completed++
+	}
+	c.Waiting = !c.Config.AutoStart
+	return ended
+}
+
+// Skip cuts a break short and moves on to the next work phase. It does
+// nothing during a work phase.
+func (c *Cycle) Skip() {
+	if c.Phase == PhaseWork {
+		return
+	}
+	c.Phase = PhaseWork
+	c.Waiting = !c.Config.AutoStart
+}
+
+// Start begins the phase Finish or Skip moved on to.
+func (c *Cycle) Start() {
+	c.Waiting = false
+}
+
+func (c *Cycle) next() string {
+	if c.Phase != PhaseWork {
+		return PhaseWork
+	}
+	if (c.Completed+1)%c.Config.LongBreakEvery == 0 {
+		return PhaseLongBreak
+	}
+	return PhaseShortBreak
+}
+
+// Position returns the set of pomodoros leading to a long break the cycle
+// is in and the pomodoro within it, both from 1. During a break it is the
+// pomodoro that just ended.
+func (c *Cycle) Position() (set, pomodoro int) {
+	n := c.Completed
+	if c.Phase != PhaseWork && n > 0 {
+		n--
+	}
+	return n/c.Config.LongBreakEvery + 1, n%c.Config.LongBreakEvery + 1
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCycle(t *testing.T) {
+	c := NewCycle(CycleConfig{Work: 25 * time.Minute, ShortBreak: 5 * time.Minute, LongBreak: 15 * time.Minute, LongBreakEvery: 2})
+
+	steps := []struct {
+		phase    string
+		duration time.Duration
+		set, n   int
+	}{
+		{PhaseWork, 25 * time.Minute, 1, 1},
+		{PhaseShortBreak, 5 * time.Minute, 1, 1},
+		{PhaseWork, 25 * time.Minute, 1, 2},
+		{PhaseLongBreak, 15 * time.Minute, 1, 2},
+		{PhaseWork, 25 * time.Minute, 2, 1},
+		{PhaseShortBreak, 5 * time.Minute, 2, 1},
+	}
+	for i, step := range steps {
+		if c.Phase != step.phase || c.Duration() != step.duration {
+			t.Fatalf("step %d: phase %q for %v, want %q for %v", i, c.Phase, c.Duration(), step.phase, step.duration)
+		}
+		if set, n := c.Position(); set != step.set || n != step.n {
+			t.Errorf("step %d: Position() = %d, %d, want %d, %d", i, set, n, step.set, step.n)
+		}
+		ended := c.Finish()
+		if ended != step.phase || !c.Waiting {
+			t.Errorf("step %d: Finish() = %q, waiting %v", i, ended, c.Waiting)
+		}
+		c.Start()
+	}
+	if c.Completed != 3 {
+		t.Errorf("Completed = %d, want 3", c.Completed)
+	}
+}
+
+func TestCycleSkipAndAutoStart(t *testing.T) {
+	c := NewCycle(CycleConfig{LongBreakEvery: 4, AutoStart: true})
+	c.Skip()
+	if c.Phase != PhaseWork || c.Completed != 0 {
+		t.Errorf("Skip() during work = %+v", c)
+	}
+
+	c.Finish()
+	if c.Phase != PhaseShortBreak || c.Waiting {
+		t.Errorf("Finish() with auto-start = %+v", c)
+	}
+	c.Skip()
+	if c.Phase != PhaseWork || c.Waiting || c.Completed != 1 {
+		t.Errorf("Skip() during a break = %+v", c)
+	}
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	offsetPattern  = regexp.MustCompile(`^([+-])(\d+)\s*([dwmy]?)$`)
+	inPattern      = regexp.MustCompile(`^in (\d+) (day|week|month|year)s?$`)
+	agoPattern     = regexp.MustCompile(`^(\d+) (day|week|month|year)s? ago$`)
+	weekPattern    = regexp.MustCompile(`^(?:week|w) ?(\d{1,2})(?: (\d{4}))?$`)
+	isoWeekPattern = regexp.MustCompile(`^(\d{4})-?w(\d{2})(?:-?([1-7]))?$`)
+)
+
+// ParseDate parses a date relative to now. It accepts:
+//
+//	today, tomorrow, yesterday
+//	2024-08-30
+//	fri, friday             the next Friday, today included
+//	next fri, last fri      the first Friday after or before today
+//	this fri                Friday of the current week
+//	+3d, -2w, +1m, +1y, +3  offsets in days (the default), weeks, months or years
+//	in 3 days, 2 weeks ago
+//	next week, last week    Monday of the following or previous week
+//	next month, last month  the first of the following or previous month
+//	week 42, week 42 2025   Monday of an ISO week, this ISO year by default
+//	2024-W42, 2024-W42-5    an ISO week or a day in it
+//	start of week, end of week, start of month, end of month, end of year
+//
+// Weeks start on Monday. The result is midnight in the local time zone.
+func ParseDate(input string, now time.Time) (time.Time, error) {
+	return parseDate(input, now, false)
+}
+
+// ParseDate is ParseDate for the vault's settings: with skip_weekend, a
+// date that lands on a weekend moves to Monday, or to Friday if it is the
+// end of a week or month, and day offsets such as tomorrow or +3d count
+// working days. Explicit dates and weekday names are taken as given.
+func (v *Vault) ParseDate(input string) (time.Time, error) {
+	return parseDate(input, time.Now(), v.config.SkipWeekend)
+}
+
+func parseDate(input string, now time.Time, skipWeekend bool) (time.Time, error) {
+	text := strings.Join(strings.Fields(strings.ToLower(input)), " ")
+	today := truncateDay(now)
+	if text == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	// Dates the user spelled out are never moved.
+	if date, err := time.ParseInLocation(taskDateLayout, text, time.Local); err == nil {
+		return date, nil
+	}
+	if day, ok := parseWeekday(text); ok {
+		return today.AddDate(0, 0, (int(day)-int(today.Weekday())+7)%7), nil
+	}
+	if m := isoWeekPattern.FindStringSubmatch(text); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		weekday := 1
+		if m[3] != "" {
+			weekday, _ = strconv.Atoi(m[3])
+		}
+		return isoWeekStart(year, week, weekday)
+	}
+	if words := strings.SplitN(text, " ", 2); len(words) == 2 {
+		if day, ok := parseWeekday(words[1]); ok {
+			switch words[0] {
+			case "next":
+				return today.AddDate(0, 0, (int(day)-int(today.Weekday())+6)%7+1), nil
+			case "last", "previous":
+				return today.AddDate(0, 0, -((int(today.Weekday())-int(day)+6)%7 + 1)), nil
+			case "this":
+				monday := today.AddDate(0, 0, -daysSinceMonday(today))
+				return monday.AddDate(0, 0, (int(day)+6)%7), nil
+			}
+		}
+	}
+
+	date, end, err := parseRelativeDate(text, today, skipWeekend)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if skipWeekend {
+		for isWeekend(date) {
+			if end {
+				date = date.AddDate(0, 0, -1)
+			} else {
+				date = date.AddDate(0, 0, 1)
+			}
+		}
+	}
+	return date, nil
+}
+
+// parseRelativeDate parses the expressions that are worked out from today.
+// end is true for the end of a week, month or year.
+func parseRelativeDate(text string, today time.Time, skipWeekend bool) (date time.Time, end bool, err error) {
+	switch text {
+	case "today", "now":
+		return today, false, nil
+	case "tomorrow":
+		return addDays(today, 1, skipWeekend), false, nil
+	case "yesterday":
+		return addDays(today, -1, skipWeekend), false, nil
+	case "next week":
+		return today.AddDate(0, 0, 7-daysSinceMonday(today)), false, nil
+	case "last week", "previous week":
+		return today.AddDate(0, 0, -7-daysSinceMonday(today)), false, nil
+	case "start of week", "beginning of week":
+		return today.AddDate(0, 0, -daysSinceMonday(today)), false, nil
+	case "end of week":
+		return today.AddDate(0, 0, 6-daysSinceMonday(today)), true, nil
+	case "next month":
+		return today.AddDate(0, 1, 1-today.Day()), false, nil
+	case "last month", "previous month":
+		return today.AddDate(0, -1, 1-today.Day()), false, nil
+	case "start of month", "beginning of month":
+		return today.AddDate(0, 0, 1-today.Day()), false, nil
+	case "end of month":
+		return today.AddDate(0, 1, -today.Day()), true, nil
+	case "end of year":
+		return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()), true, nil
+	}
+
+	var sign, n int
+	var unit string
+	if m := offsetPattern.FindStringSubmatch(text); m != nil {
+		sign, unit = 1, m[3]
+		if m[1] == "-" {
+			sign = -1
+		}
+		n, _ = strconv.Atoi(m[2])
+	} else if m := inPattern.FindStringSubmatch(text); m != nil {
+		sign, unit = 1, m[2][:1]
+		n, _ = strconv.Atoi(m[1])
+	} else if m := agoPattern.FindStringSubmatch(text); m != nil {
+		sign, unit = -1, m[2][:1]
+		n, _ = strconv.Atoi(m[1])
+	}
+	if sign != 0 {
+		switch unit {
+		case "w":
+			return today.AddDate(0, 0, sign*7*n), false, nil
+		case "m":
+			return today.AddDate(0, sign*n, 0), false, nil
+		case "y":
+			return today.AddDate(sign*n, 0, 0), false, nil
+		}
+		return addDays(today, sign*n, skipWeekend), false, nil
+	}
+
+	if m := weekPattern.FindStringSubmatch(text); m != nil {
+		year, _ := today.ISOWeek()
+		if m[2] != "" {
+			year, _ = strconv.Atoi(m[2])
+		}
+		week, _ := strconv.Atoi(m[1])
+		date, err := isoWeekStart(year, week, 1)
+		return date, false, err
+	}
+	return time.Time{}, false, fmt.Errorf("unrecognised date %q", text)
+}
+
+// addDays moves n days from date, counting only working days with skipWeekend.
+func addDays(date time.Time, n int, skipWeekend bool) time.Time {
+	if !skipWeekend {
+		return date.AddDate(0, 0, n)
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for ; n > 0; n-- {
+		date = date.AddDate(0, 0, step)
+		for isWeekend(date) {
+			date = date.AddDate(0, 0, step)
+		}
+	}
+	return date
+}
+
+// isoWeekStart returns the given day (1 for Monday to 7 for Sunday) of an ISO week.
+func isoWeekStart(year, week, weekday int) (time.Time, error) {
+	if week < 1 || week > 53 {
+		return time.Time{}, fmt.Errorf("invalid ISO week %d", week)
+	}
+	// January 4th is always in week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+	date := jan4.AddDate(0, 0, -daysSinceMonday(jan4)+7*(week-1)+weekday-1)
+	if y, w := date.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("%d has no ISO week %d", year, week)
+	}
+	return date, nil
+}
+
+func daysSinceMonday(date time.Time) int {
+	return (int(date.Weekday()) + 6) % 7
+}
+
+func isWeekend(date time.Time) bool {
+	return date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// A Friday afternoon.
+	now := time.Date(2024, 8, 30, 15, 4, 0, 0, time.Local)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"today", "2024-08-30"},
+		{"Tomorrow", "2024-08-31"},
+		{"yesterday", "2024-08-29"},
+		{"2024-01-02", "2024-01-02"},
+		{"fri", "2024-08-30"},
+		{"monday", "2024-09-02"},
+		{"next fri", "2024-09-06"},
+		{"next  Monday", "2024-09-02"},
+		{"last fri", "2024-08-23"},
+		{"last wed", "2024-08-28"},
+		{"this mon", "2024-08-26"},
+		{"this sunday", "2024-09-01"},
+		{"+3d", "2024-09-02"},
+		{"+3", "2024-09-02"},
+		{"-2w", "2024-08-16"},
+		{"+1m", "2024-09-30"},
+		{"-1y", "2023-08-30"},
+		{"in 2 weeks", "2024-09-13"},
+		{"3 days ago", "2024-08-27"},
+		{"next week", "2024-09-02"},
+		{"last week", "2024-08-19"},
+		{"next month", "2024-09-01"},
+		{"end of month", "2024-08-31"},
+		{"start of month", "2024-08-01"},
+		{"end of week", "2024-09-01"},
+		{"end of year", "2024-12-31"},
+		{"week 42", "2024-10-14"},
+		{"week 1 2021", "2021-01-04"},
+		{"2024-W42", "2024-10-14"},
+		{"2024w42-5", "2024-10-18"},
+		{"2020-W53", "2020-12-28"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.input, now)
+		if err != nil {
+			t.Errorf("ParseDate(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got.Format(taskDateLayout) != tt.want || got.Hour() != 0 {
+			t.Errorf("ParseDate(%q) = %v, want %s", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "someday", "week 54", "2021-W53", "next blursday", "+3x"} {
+		if _, err := ParseDate(input, now); err == nil {
+			t.Errorf("ParseDate(%q) should fail", input)
+		}
+	}
+}
+
+func TestParseDateSkipWeekend(t *testing.T) {
+	now := time.Date(2024, 8, 30, 15, 4, 0, 0, time.Local) // Friday
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"tomorrow", "2024-09-02"},
+		{"+3d", "2024-09-04"},
+		{"-5d", "2024-08-23"},
+		{"end of month", "2024-08-30"},
+		{"end of week", "2024-08-30"},
+		{"+1w", "2024-09-06"},
+		{"sat", "2024-08-31"},
+		{"2024-08-31", "2024-08-31"},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.input, now, true)
+		if err != nil {
+			t.Errorf("parseDate(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got.Format(taskDateLayout) != tt.want {
+			t.Errorf("parseDate(%q) = %s, want %s", tt.input, got.Format(taskDateLayout), tt.want)
+		}
+	}
+}
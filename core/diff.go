@@ -0,0 +1,72 @@
+package core
+
+import "strings"
+
+// DiffLines compares two texts line by line and returns a unified-style
+// diff: changed lines prefixed with "-" or "+", and up to context unchanged
+// lines around them prefixed with " ". Runs of unchanged lines that are
+// left out are marked by a "@@" line.
+func DiffLines(old, new string, context int) []string {
+	a, b := splitLines([]byte(old)), splitLines([]byte(new))
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var all []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			all = append(all, " "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			all = append(all, "-"+a[i])
+			i++
+		default:
+			all = append(all, "+"+b[j])
+			j++
+		}
+	}
+
+	// Keep changed lines and the context around them.
+	keep := make([]bool, len(all))
+	for k, line := range all {
+		if line[0] == ' ' {
+			continue
+		}
+		for c := k - context; c <= k+context; c++ {
+			if c >= 0 && c < len(all) {
+				keep[c] = true
+			}
+		}
+	}
+	var diff []string
+	skipped := false
+	for k, line := range all {
+		if !keep[k] {
+			skipped = true
+			continue
+		}
+		if skipped && len(diff) > 0 {
+			diff = append(diff, "@@")
+		}
+		skipped = false
+		diff = append(diff, strings.TrimRight(line, " "))
+	}
+	return diff
+}
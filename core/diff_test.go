@@ -0,0 +1,22 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\n"
+
+	want := []string{" a", "-b", "+B", " c", "@@", " g", "+h"}
+	if got := DiffLines(old, new, 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines() = %q, want %q", got, want)
+	}
+	if got := DiffLines("", "x\n", 3); !reflect.DeepEqual(got, []string{"+x"}) {
+		t.Errorf("DiffLines() of new file = %q", got)
+	}
+	if got := DiffLines(old, old, 3); len(got) != 0 {
+		t.Errorf("DiffLines() of equal texts = %q, want nothing", got)
+	}
+}
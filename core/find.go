@@ -0,0 +1,130 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by FindTask when no task matches the query.
+var ErrNotFound = errors.New("no matching task")
+
+// AmbiguousError is returned by FindTask when several tasks match equally well.
+type AmbiguousError struct {
+	Query   string
+	Matches []Task
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q matches %d tasks:", e.Query, len(e.Matches))
+	for _, task := range e.Matches {
+		fmt.Fprintf(&b, "\n  :%d  %s", task.LineNumber, strings.TrimSpace(task.String()))
+	}
+	b.WriteString("\nuse :<line> or a more specific text")
+	return b.String()
+}
+
+// Match quality, best first.
+const (
+	matchNone = iota
+	matchFuzzy
+	matchSubstring
+	matchExact
+	matchID
+)
+
+// FindTask returns the single task of the period containing date that
+// matches query and filter. The query is tried, in order, as:
+//
+//	:N       the task on line N
+//	^id, id  the task with that block ID
+//	text     a task whose text equals, contains or fuzzily matches it
+//
+// Only the best kind of match counts; more than one such match is an
+// *AmbiguousError.
+func (v *Vault) FindTask(date time.Time, query string, filter TaskFilter) (Task, error) {
+	filename := v.getFilename(date)
+	if !fileExists(filename) {
+		return Task{}, fmt.Errorf("%w: %s does not exist", ErrNotFound, filename)
+	}
+	tasks, err := v.linesWithSelection(date)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if strings.HasPrefix(query, ":") {
+		lineNumber, err := strconv.Atoi(query[1:])
+		if err != nil {
+			return Task{}, fmt.Errorf("invalid line number %q", query)
+		}
+		for _, task := range tasks {
+			if task.LineNumber != lineNumber {
+				continue
+			}
+			if !filter.Match(task) {
+				return Task{}, fmt.Errorf("%w: the task on line %d %s", ErrNotFound, lineNumber, filter.describeMismatch(task))
+			}
+			return task, nil
+		}
+		return Task{}, fmt.Errorf("%w: no task on line %d", ErrNotFound, lineNumber)
+	}
+
+	best := matchNone
+	var matches []Task
+	for _, task := range tasks {
+		if !filter.Match(task) {
+			continue
+		}
+		quality := matchQuality(task, query)
+		if quality == matchNone || quality < best {
+			continue
+		}
+		if quality > best {
+			best = quality
+			matches = nil
+		}
+		matches = append(matches, task)
+	}
+
+	switch len(matches) {
+	case 0:
+		return Task{}, fmt.Errorf("%w: %q", ErrNotFound, query)
+	case 1:
+		return matches[0], nil
+	}
+	return Task{}, &AmbiguousError{Query: query, Matches: matches}
+}
+
+func matchQuality(task Task, query string) int {
+	if task.ID != "" && strings.TrimPrefix(query, "^") == task.ID {
+		return matchID
+	}
+	text := strings.ToLower(strings.TrimSpace(task.Text))
+	query = strings.ToLower(strings.TrimSpace(query))
+	switch {
+	case query == "":
+		return matchNone
+	case text == query:
+		return matchExact
+	case strings.Contains(text, query):
+		return matchSubstring
+	case isSubsequence(query, text):
+		return matchFuzzy
+	}
+	return matchNone
+}
+
+// isSubsequence reports whether the letters of query appear in text in order.
+func isSubsequence(query, text string) bool {
+	q := []rune(query)
+	i := 0
+	for _, r := range text {
+		if i < len(q) && r == q[i] {
+			i++
+		}
+	}
+	return i == len(q)
+}
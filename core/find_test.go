@@ -0,0 +1,54 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFindTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	writeTestFile(t, v, testDate, "Header\n\n- [ ] review PR\n- [ ] review PR #42\n- [x] write standup notes ^standup\n- [ ] Water plants\n")
+
+	tests := []struct {
+		query    string
+		filter   TaskFilter
+		wantLine int
+		wantErr  error
+	}{
+		{query: "review PR", wantLine: 3},             // exact beats substring
+		{query: "#42", wantLine: 4},                   // substring
+		{query: "standup", wantLine: 5},               // block ID
+		{query: "^standup", wantLine: 5},              // block ID with caret
+		{query: "wtr plnts", wantLine: 6},             // fuzzy
+		{query: ":4", wantLine: 4},                    // line number
+		{query: "review", wantErr: &AmbiguousError{}}, // two substring matches
+		{query: "review", filter: TaskFilter{Text: "42"}, wantLine: 4},
+		{query: "standup", filter: TaskFilter{Status: "open"}, wantErr: ErrNotFound},
+		{query: "nothing like it", wantErr: ErrNotFound},
+		{query: ":1", wantErr: ErrNotFound},
+		{query: ":5", filter: TaskFilter{Status: "open"}, wantErr: ErrNotFound}, // already done
+		{query: ":4", filter: TaskFilter{Status: "done"}, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		task, err := v.FindTask(testDate, tt.query, tt.filter)
+		switch want := tt.wantErr.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("FindTask(%q) error = %v", tt.query, err)
+			} else if task.LineNumber != tt.wantLine {
+				t.Errorf("FindTask(%q) = line %d, want %d", tt.query, task.LineNumber, tt.wantLine)
+			}
+		case *AmbiguousError:
+			var ambiguous *AmbiguousError
+			if !errors.As(err, &ambiguous) || len(ambiguous.Matches) != 2 {
+				t.Errorf("FindTask(%q) error = %v, want two ambiguous matches", tt.query, err)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("FindTask(%q) error = %v, want %v", tt.query, err, want)
+			}
+		}
+	}
+}
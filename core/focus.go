@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	pomodoroPattern = regexp.MustCompile(`(?:^|\s)🍅(\d+)`)
+	focusPattern    = regexp.MustCompile(`(?:^|\s)focus:(\d+)m`)
+)
+
+// FocusText is the task text without its focus annotations, for display
+// next to Pomodoros and Focus.
+func (t Task) FocusText() string {
+	text := pomodoroPattern.ReplaceAllString(t.Text, "")
+	return strings.TrimSpace(focusPattern.ReplaceAllString(text, ""))
+}
+
+// annotateFocus replaces the focus annotations of text with "🍅N focus:Mm",
+// keeping a trailing ^id last.
+func annotateFocus(text string, pomodoros int, focus time.Duration) string {
+	text = pomodoroPattern.ReplaceAllString(text, "")
+	text = strings.TrimSpace(focusPattern.ReplaceAllString(text, ""))
+
+	var note []string
+	if pomodoros > 0 {
+		note = append(note, "🍅"+strconv.Itoa(pomodoros))
+	}
+	if minutes := int(focus.Minutes()); minutes > 0 {
+		note = append(note, fmt.Sprintf("focus:%dm", minutes))
+	}
+	if len(note) == 0 {
+		return text
+	}
+	if m := idPattern.FindStringIndex(text); m != nil {
+		return strings.TrimSpace(text[:m[0]]) + " " + strings.Join(note, " ") + text[m[0]:]
+	}
+	return strings.TrimSpace(text + " " + strings.Join(note, " "))
+}
+
+// RecordFocus counts the pomodoro session s against task, read from the
+// period file for date: a completed session adds a 🍅 and every session
+// adds the time spent focused. As sessions are long, a task whose line has
+// changed since is found again by its text.
+func (v *Vault) RecordFocus(date time.Time, task Task, s Session) error {
+	addr := task.Address(date)
+	key := carryKey(task.Text)
+	return v.updateFile(date, func(content []byte) ([]byte, error) {
+		lines := strings.Split(string(content), "\n")
+		i, err := addr.resolve(lines)
+		if errors.Is(err, ErrConflict) {
+			i = findTaskLine(lines, key)
+		} else if err != nil {
+			return nil, err
+		}
+		if i < 0 {
+			return nil, err
+		}
+
+		current, _ := ParseTask(lines[i], i+1)
+		pomodoros := current.Pomodoros
+		if s.Status == SessionCompleted {
+			pomodoros++
+		}
+		// The line keeps whole minutes, so the session is rounded before
+		// it is added rather than losing its seconds every time.
+		current.setText(annotateFocus(current.Text, pomodoros, current.Focus+s.Focused().Round(time.Minute)))
+		lines[i] = current.String()
+		return []byte(strings.Join(lines, "\n")), nil
+	})
+}
+
+// findTaskLine returns the index of the first task in lines whose carryKey
+// is key, or -1.
+func findTaskLine(lines []string, key string) int {
+	for i, line := range lines {
+		if task, ok := ParseTask(line, i+1); ok && carryKey(task.Text) == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnotateFocus(t *testing.T) {
+	tests := []struct {
+		text      string
+		pomodoros int
+		focus     time.Duration
+		want      string
+	}{
+		{"Write report", 1, 25 * time.Minute, "Write report 🍅1 focus:25m"},
+		{"Write report 🍅1 focus:25m", 2, 50 * time.Minute, "Write report 🍅2 focus:50m"},
+		{"Write report", 0, 10 * time.Minute, "Write report focus:10m"},
+		{"Write report", 0, 30 * time.Second, "Write report"},
+		{"Write report ^rep", 1, 25 * time.Minute, "Write report 🍅1 focus:25m ^rep"},
+	}
+	for _, tt := range tests {
+		if got := annotateFocus(tt.text, tt.pomodoros, tt.focus); got != tt.want {
+			t.Errorf("annotateFocus(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	task, _ := ParseTask("- [ ] Write report 🍅2 focus:50m carried:1 from:2024-08-29", 1)
+	if task.Pomodoros != 2 || task.Focus != 50*time.Minute || task.FocusText() != "Write report carried:1 from:2024-08-29" {
+		t.Errorf("ParseTask() = %+v", task)
+	}
+	if carryKey(task.Text) != "Write report" {
+		t.Errorf("carryKey() = %q", carryKey(task.Text))
+	}
+}
+
+func TestRecordFocus(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	day := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+	filename := writeTestFile(t, v, day, "- [ ] Write report\n- [ ] Other\n")
+
+	task, _ := ParseTask("- [ ] Write report", 1)
+	completed := Session{Duration: 25, Actual: 25 * 60, Status: SessionCompleted}
+	if err := v.RecordFocus(day, task, completed); err != nil {
+		t.Fatalf("RecordFocus() error = %v", err)
+	}
+
+	// The line changed meanwhile: the task is found again by its text.
+	if err := os.WriteFile(filename, []byte("- [ ] Other\n- [ ] Write report 🍅1 focus:25m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	abandoned := Session{Duration: 25, Actual: 10 * 60, Status: SessionAbandoned}
+	if err := v.RecordFocus(day, task, abandoned); err != nil {
+		t.Fatalf("RecordFocus() after a change error = %v", err)
+	}
+
+	content, _ := os.ReadFile(filename)
+	if want := "- [ ] Other\n- [ ] Write report 🍅1 focus:35m\n"; string(content) != want {
+		t.Errorf("file = %q, want %q", content, want)
+	}
+
+	gone, _ := ParseTask("- [ ] Not there", 5)
+	if err := v.RecordFocus(day, gone, completed); err == nil {
+		t.Error("RecordFocus() on a missing task should fail")
+	}
+}
+
+func TestRecordFocusRoundsSessions(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	day := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+	filename := writeTestFile(t, v, day, "- [ ] Write report\n")
+
+	tests := []struct {
+		actual int // seconds
+		want   string
+	}{
+		{40, "- [ ] Write report focus:1m\n"},
+		{20, "- [ ] Write report focus:1m\n"},
+		{150, "- [ ] Write report focus:4m\n"},
+		{89, "- [ ] Write report focus:5m\n"},
+	}
+	for _, tt := range tests {
+		task, _ := ParseTask(strings.TrimSuffix(readTestFile(t, filename), "\n"), 1)
+		s := Session{Duration: 25, Actual: tt.actual, Status: SessionAbandoned}
+		if err := v.RecordFocus(day, task, s); err != nil {
+			t.Fatalf("RecordFocus() error = %v", err)
+		}
+		if got := readTestFile(t, filename); got != tt.want {
+			t.Errorf("after %ds file = %q, want %q", tt.actual, got, tt.want)
+		}
+	}
+}
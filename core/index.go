@@ -0,0 +1,191 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexVersion is bumped whenever the cached data changes shape, which
+// makes older index files be rebuilt from scratch.
+const indexVersion = 3
+
+// IndexedFile is a period file of the vault with its parsed tasks.
+type IndexedFile struct {
+	Filename string
+	Mode     string    // the layout the file is in: daily, weekly or monthly
+	Date     time.Time // the first day it holds
+	Tasks    []Task
+}
+
+// indexEntry is an IndexedFile as cached, with what it was parsed from.
+type indexEntry struct {
+	IndexedFile
+	ModTime time.Time
+	Size    int64
+}
+
+type indexData struct {
+	Version int
+	Files   map[string]indexEntry // keyed by path relative to the vault
+}
+
+// indexFile is where the index is cached, in the vault root.
+func (v *Vault) indexFile() string {
+	return filepath.Join(v.config.VaultLoc, ".index")
+}
+
+// Index returns every period file in the vault, in any layout, ordered by
+// date. Parsed files are cached in .index in the vault root, keyed by path,
+// modification time and size, so only files that changed since the last
+// call are read again. If the cache cannot be written the vault is simply
+// read in full.
+func (v *Vault) Index() ([]IndexedFile, error) {
+	var index indexData
+	locked := false
+	err := v.withLock(v.indexFile(), func() error {
+		locked = true
+		index = v.loadIndex()
+		changed, err := v.refreshIndex(&index)
+		if err != nil || !changed {
+			return err
+		}
+		// The index is only a cache, so failing to store it is no error.
+		v.saveIndex(index)
+		return nil
+	})
+	if !locked {
+		// Without the lock, in a read-only vault for example, read everything.
+		index = indexData{Version: indexVersion, Files: map[string]indexEntry{}}
+		_, err = v.refreshIndex(&index)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]IndexedFile, 0, len(index.Files))
+	for _, entry := range index.Files {
+		files = append(files, entry.IndexedFile)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].Date.Equal(files[j].Date) {
+			return files[i].Date.Before(files[j].Date)
+		}
+		return files[i].Filename < files[j].Filename
+	})
+	return files, nil
+}
+
+// loadIndex reads the cached index, or returns an empty one if there is
+// none or it cannot be used.
+func (v *Vault) loadIndex() indexData {
+	empty := indexData{Version: indexVersion, Files: map[string]indexEntry{}}
+	content, err := os.ReadFile(v.indexFile())
+	if err != nil {
+		return empty
+	}
+	var index indexData
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&index); err != nil || index.Version != indexVersion {
+		return empty
+	}
+	for key, entry := range index.Files {
+		entry.Filename = filepath.Join(v.config.VaultLoc, key)
+		entry.Date = time.Date(entry.Date.Year(), entry.Date.Month(), entry.Date.Day(), 0, 0, 0, 0, time.Local)
+		for i := range entry.Tasks {
+			entry.Tasks[i].restoreLine()
+		}
+		index.Files[key] = entry
+	}
+	return index
+}
+
+func (v *Vault) saveIndex(index indexData) error {
+	var out bytes.Buffer
+	if err := gob.NewEncoder(&out).Encode(index); err != nil {
+		return fmt.Errorf("error encoding index: %w", err)
+	}
+	return writeFileAtomic(v.indexFile(), out.Bytes())
+}
+
+// refreshIndex re-reads the period files that are new or changed since they
+// were indexed and forgets those that are gone. It reports whether anything
+// changed.
+func (v *Vault) refreshIndex(index *indexData) (bool, error) {
+	changed := false
+	seen := map[string]bool{}
+	err := filepath.WalkDir(v.config.VaultLoc, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == v.config.VaultLoc {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(path, ".md") || path == v.templateFile() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		key, err := filepath.Rel(v.config.VaultLoc, path)
+		if err != nil {
+			return err
+		}
+
+		cached, ok := index.Files[key]
+		if ok && cached.ModTime.Equal(info.ModTime()) && cached.Size == info.Size() {
+			seen[key] = true
+			return nil
+		}
+		file, ok := v.parsePeriodFile(path)
+		if !ok {
+			return nil
+		}
+		tasks, err := readTasks(path)
+		if err != nil {
+			return err
+		}
+		seen[key] = true
+		index.Files[key] = indexEntry{
+			IndexedFile: IndexedFile{Filename: path, Mode: file.mode, Date: file.date, Tasks: tasks},
+			ModTime:     info.ModTime(),
+			Size:        info.Size(),
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error reading vault: %w", err)
+	}
+
+	for key := range index.Files {
+		if !seen[key] {
+			delete(index.Files, key)
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// readTasks parses the tasks of the period file filename.
+func readTasks(filename string) ([]Task, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseTasks(file)
+}
+
+// restoreLine re-derives what a task read back from the index keeps in
+// unexported fields, which are not cached.
+func (t *Task) restoreLine() {
+	rest := strings.TrimPrefix(t.Line, t.Indent)
+	t.noSpace = len(rest) > 5 && rest[5] != ' '
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIndex(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	day := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+
+	first := writeTestFile(t, v, day.AddDate(0, 0, -1), "- [x]Yesterday\n")
+	second := writeTestFile(t, v, day, "- [ ] Today\n")
+
+	files, err := v.Index()
+	if err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	if len(files) != 2 || files[0].Filename != first || files[1].Filename != second {
+		t.Fatalf("Index() = %+v", files)
+	}
+	if !fileExists(v.indexFile()) {
+		t.Fatal("Index() did not write the index")
+	}
+
+	// A second call reads the cached tasks back.
+	files, err = v.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := files[0].Tasks[0]
+	if task.Text != "Yesterday" || !task.Selected || !task.noSpace || !files[0].Date.Equal(day.AddDate(0, 0, -1)) {
+		t.Errorf("cached task = %+v", task)
+	}
+
+	// Changed files are read again and removed ones are dropped.
+	writeTestFile(t, v, day, "- [ ] Today\n- [ ] And more\n")
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	files, err = v.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || len(files[0].Tasks) != 2 {
+		t.Errorf("Index() after changes = %+v", files)
+	}
+}
+
+func TestIndexRebuildsUnusableCache(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	writeTestFile(t, v, time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local), "- [ ] Today\n")
+
+	if err := os.WriteFile(v.indexFile(), []byte("not an index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := v.Index()
+	if err != nil || len(files) != 1 || len(files[0].Tasks) != 1 {
+		t.Fatalf("Index() with a corrupt cache = %+v, %v", files, err)
+	}
+
+	index := v.loadIndex()
+	if len(index.Files) != 1 {
+		t.Errorf("the corrupt cache was not replaced: %+v", index)
+	}
+	index.Version = indexVersion + 1
+	if err := v.saveIndex(index); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.loadIndex(); len(got.Files) != 0 {
+		t.Errorf("loadIndex() kept an index of another version: %+v", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package core
+
+import "os"
+
+// Advisory locking is not implemented on Windows; writes are still atomic.
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
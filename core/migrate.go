@@ -0,0 +1,345 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is a planned change of storage layout, see PlanMigration.
+type Migration struct {
+	Mode    string       // the interval mode migrated to
+	Changes []FileChange // period files written in the new layout
+	Removed []FileChange // period files folded into one of the Changes
+}
+
+// FileChange is the old and new content of one period file. Old is nil for
+// a file that does not exist yet, New is nil for a file that is removed.
+type FileChange struct {
+	Filename string
+	Old      []byte
+	New      []byte
+	Sources  []string // the files whose tasks end up in this one
+}
+
+var (
+	dailyFilePattern  = regexp.MustCompile(`^\d{2}\.md$`)
+	weeklyFilePattern = regexp.MustCompile(`^week\d+\.md$`)
+)
+
+// periodFile is a period file found in the vault together with the layout it
+// was written in and the first day it holds.
+type periodFile struct {
+	filename string
+	mode     string
+	date     time.Time
+}
+
+// periodFiles walks the vault for files in any of the daily, weekly or
+// monthly layouts, ordered by date.
+func (v *Vault) periodFiles() ([]periodFile, error) {
+	var files []periodFile
+	err := filepath.WalkDir(v.config.VaultLoc, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == v.config.VaultLoc {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || path == v.templateFile() {
+			return nil
+		}
+		if file, ok := v.parsePeriodFile(path); ok {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading vault: %w", err)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].date.Before(files[j].date) })
+	return files, nil
+}
+
+// parsePeriodFile recognises path as YEAR/MONTH/NAME.md in one of the layouts
+// and finds the first day getFilename maps to it in that layout.
+func (v *Vault) parsePeriodFile(path string) (periodFile, bool) {
+	rel, err := filepath.Rel(v.config.VaultLoc, path)
+	if err != nil {
+		return periodFile{}, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) != 3 {
+		return periodFile{}, false
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return periodFile{}, false
+	}
+	month, err := time.Parse("January", parts[1])
+	if err != nil {
+		return periodFile{}, false
+	}
+
+	var mode string
+	switch name := parts[2]; {
+	case dailyFilePattern.MatchString(name):
+		mode = "daily"
+	case weeklyFilePattern.MatchString(name):
+		mode = "weekly"
+	case name == parts[1]+".md":
+		mode = "monthly"
+	default:
+		return periodFile{}, false
+	}
+
+	// Weekly and monthly files are named by ISO year, which differs from the
+	// calendar year around New Year, so look in the neighbouring years too.
+	layout := v.withMode(mode)
+	for y := year - 1; y <= year+1; y++ {
+		start := time.Date(y, month.Month(), 1, 0, 0, 0, 0, time.Local)
+		for day := start; day.Month() == start.Month(); day = day.AddDate(0, 0, 1) {
+			if layout.getFilename(day) == path {
+				return periodFile{filename: path, mode: mode, date: day}, true
+			}
+		}
+	}
+	return periodFile{}, false
+}
+
+// PlanMigration works out how to store every period file of the vault in the
+// layout of mode. Files that map to the same file in the new layout are
+// merged in date order: a task that is already there, ignoring carry
+// annotations, is not added again but is checked if any copy was done.
+// Nothing is written; pass the plan to ApplyMigration.
+func (v *Vault) PlanMigration(mode string) (*Migration, error) {
+	if mode != "daily" && mode != "weekly" && mode != "monthly" {
+		return nil, fmt.Errorf("unknown interval mode %q: expected daily, weekly or monthly", mode)
+	}
+	target := v.withMode(mode)
+
+	files, err := v.periodFiles()
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string][]periodFile{}
+	var order []string
+	for _, file := range files {
+		filename := target.getFilename(file.date)
+		if groups[filename] == nil {
+			order = append(order, filename)
+		}
+		groups[filename] = append(groups[filename], file)
+	}
+
+	migration := &Migration{Mode: mode}
+	for _, filename := range order {
+		group := groups[filename]
+		if len(group) == 1 && group[0].filename == filename {
+			continue
+		}
+
+		change := FileChange{Filename: filename}
+		var lines []string
+		if content, err := os.ReadFile(filename); err == nil {
+			change.Old = content
+			lines = splitLines(content)
+		} else if os.IsNotExist(err) {
+			content, err := target.renderTemplate(group[0].date)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(splitLines(content), "")
+		} else {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+
+		for _, file := range group {
+			change.Sources = append(change.Sources, file.filename)
+			if file.filename == filename {
+				continue
+			}
+			content, err := os.ReadFile(file.filename)
+			if err != nil {
+				return nil, fmt.Errorf("error reading file: %w", err)
+			}
+			lines = v.mergePeriod(lines, content, file)
+			migration.Removed = append(migration.Removed, FileChange{
+				Filename: file.filename,
+				Old:      content,
+				Sources:  []string{file.filename},
+			})
+		}
+		lines = splitLines([]byte(strings.Join(lines, "\n")))
+		change.New = []byte(strings.Join(lines, "\n") + "\n")
+		migration.Changes = append(migration.Changes, change)
+	}
+	return migration, nil
+}
+
+// splitLines splits content into lines without the trailing blank ones.
+func splitLines(content []byte) []string {
+	lines := strings.Split(string(content), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// mergePeriod merges the tasks of the period file content into lines, then
+// appends any other text of the file except its generated header.
+func (v *Vault) mergePeriod(lines []string, content []byte, file periodFile) []string {
+	header := strings.TrimSpace(v.withMode(file.mode).GetHeader(file.date))
+
+	source := splitLines(content)
+	var tasks []Task
+	var notes []string
+	for i, line := range source {
+		if task, ok := ParseTask(line, i+1); ok {
+			if strings.TrimSpace(task.Text) != "" {
+				tasks = append(tasks, task)
+			}
+		} else if trimmed := strings.TrimSpace(line); trimmed != "" && trimmed != header {
+			notes = append(notes, line)
+		}
+	}
+
+	// Keep the indentation of the file merged into, unless it has no subtasks yet.
+	unit := indentUnit(source)
+	for i, line := range lines {
+		if task, ok := ParseTask(line, i+1); ok && task.Indent != "" {
+			unit = indentUnit(lines)
+			break
+		}
+	}
+	lines = mergeTasks(lines, -1, BuildTaskTree(tasks), unit)
+
+	existing := map[string]bool{}
+	for _, line := range lines {
+		existing[strings.TrimSpace(line)] = true
+	}
+	for _, note := range notes {
+		if !existing[strings.TrimSpace(note)] {
+			lines = append(lines, note)
+		}
+	}
+	return lines
+}
+
+// mergeTasks merges nodes into lines as subtasks of lines[parent], or at the
+// top level if parent is -1. A node whose task is already there is merged
+// into it, anything else is inserted after the parent's last subtask or the
+// last task of the file.
+func mergeTasks(lines []string, parent int, nodes []*TaskNode, unit string) []string {
+	indent := ""
+	if parent >= 0 {
+		task, _ := ParseTask(lines[parent], parent+1)
+		indent = task.Indent + unit
+	}
+
+	for _, node := range nodes {
+		i := findSubtask(lines, parent, carryKey(node.Task.Text))
+		if i >= 0 {
+			if node.Task.Selected {
+				setLineStatus(lines, i, true, false)
+			}
+			lines = mergeTasks(lines, i, node.Children, unit)
+			continue
+		}
+
+		at := lastTaskEnd(lines)
+		if parent >= 0 {
+			at = subtreeEnd(lines, parent)
+		}
+		block := renderTaskTree(node, indent, unit)
+		lines = append(lines[:at], append(block, lines[at:]...)...)
+	}
+	return lines
+}
+
+// lastTaskEnd returns the index just past the last task in lines, or the end
+// of lines if there are no tasks, so that new tasks go before trailing notes.
+func lastTaskEnd(lines []string) int {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if _, ok := ParseTask(lines[i], i+1); ok {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// findSubtask returns the index of the direct subtask of lines[parent] (or
+// top-level task if parent is -1) whose text is key, or -1.
+func findSubtask(lines []string, parent int, key string) int {
+	start, end := 0, len(lines)
+	if parent >= 0 {
+		start, end = parent+1, subtreeEnd(lines, parent)
+	}
+	for i := start; i < end; i++ {
+		task, ok := ParseTask(lines[i], i+1)
+		if ok && carryKey(task.Text) == key && parentTaskIndex(lines, i) == parent {
+			return i
+		}
+	}
+	return -1
+}
+
+// renderTaskTree renders node and its subtasks, node indented by indent.
+func renderTaskTree(node *TaskNode, indent, unit string) []string {
+	task := node.Task
+	task.Indent = indent
+	lines := []string{task.String()}
+	for _, child := range node.Children {
+		lines = append(lines, renderTaskTree(child, indent+unit, unit)...)
+	}
+	return lines
+}
+
+// ApplyMigration writes the files of a plan made by PlanMigration. Every file
+// involved is locked first and must still have the content it was planned
+// from, otherwise nothing is written and the error wraps ErrConflict.
+// Removed files are kept as backups, like the replaced versions of files.
+func (v *Vault) ApplyMigration(m *Migration) error {
+	all := append(append([]FileChange(nil), m.Changes...), m.Removed...)
+	var filenames []string
+	for _, change := range all {
+		filenames = append(filenames, change.Filename)
+	}
+
+	return v.withLocks(filenames, func() error {
+		for _, change := range all {
+			content, err := os.ReadFile(change.Filename)
+			if os.IsNotExist(err) {
+				content, err = nil, nil
+			}
+			if err != nil {
+				return fmt.Errorf("error reading file: %w", err)
+			}
+			if !bytes.Equal(content, change.Old) {
+				return fmt.Errorf("%w: %s", ErrConflict, change.Filename)
+			}
+		}
+
+		for _, change := range m.Changes {
+			if err := replaceFile(change.Filename, change.Old, change.New); err != nil {
+				return err
+			}
+		}
+		for _, change := range m.Removed {
+			if err := writeFileAtomic(change.Filename+backupSuffix, change.Old); err != nil {
+				return fmt.Errorf("error writing backup: %w", err)
+			}
+			if err := os.Remove(change.Filename); err != nil {
+				return fmt.Errorf("error removing file: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanMigrationDailyToWeekly(t *testing.T) {
+	dir := t.TempDir()
+	daily := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	weekly := NewVault(Config{VaultLoc: dir, IntervalMode: "weekly"})
+	mon := time.Date(2024, 8, 26, 0, 0, 0, 0, time.Local)
+	tue := mon.AddDate(0, 0, 1)
+	nextMon := mon.AddDate(0, 0, 7)
+
+	monFile := writeTestFile(t, daily, mon, "2024-08-26 Monday\n\n- [ ] Review PR\n    - [ ] Tests\n- [x] Standup\nNotes from Monday\n")
+	tueFile := writeTestFile(t, daily, tue, "2024-08-27 Tuesday\n\n- [x] Review PR carried:1 from:2024-08-26\n    - [x] Tests\n    - [ ] Docs\n- [ ] Deploy\n")
+	writeTestFile(t, daily, nextMon, "2024-09-02 Monday\n\n- [ ] Plan\n")
+
+	migration, err := daily.PlanMigration("weekly")
+	if err != nil {
+		t.Fatalf("PlanMigration() error = %v", err)
+	}
+	if len(migration.Changes) != 2 || len(migration.Removed) != 3 {
+		t.Fatalf("got %d changes and %d removals, want 2 and 3", len(migration.Changes), len(migration.Removed))
+	}
+
+	change := migration.Changes[0]
+	if change.Filename != weekly.getFilename(mon) || change.Old != nil {
+		t.Errorf("change = %s (old %q), want new file %s", change.Filename, change.Old, weekly.getFilename(mon))
+	}
+	if !reflect.DeepEqual(change.Sources, []string{monFile, tueFile}) {
+		t.Errorf("Sources = %v", change.Sources)
+	}
+	want := "Week 35\n\n- [x] Review PR\n    - [x] Tests\n    - [ ] Docs\n- [x] Standup\n- [ ] Deploy\nNotes from Monday\n"
+	if got := string(change.New); got != want {
+		t.Errorf("New = %q, want %q", got, want)
+	}
+
+	// Nothing is written until the plan is applied.
+	if fileExists(change.Filename) || !fileExists(monFile) {
+		t.Fatal("PlanMigration() changed files")
+	}
+	if err := daily.ApplyMigration(migration); err != nil {
+		t.Fatalf("ApplyMigration() error = %v", err)
+	}
+	if got := readTestFile(t, change.Filename); got != want {
+		t.Errorf("migrated content = %q, want %q", got, want)
+	}
+	if fileExists(monFile) || !fileExists(monFile+backupSuffix) {
+		t.Error("source file should be replaced by a backup")
+	}
+
+	tasks, err := weekly.LoadLinesWithSelection(tue)
+	if err != nil || len(tasks) != 5 {
+		t.Errorf("LoadLinesWithSelection() = %d tasks, %v; want 5", len(tasks), err)
+	}
+
+	// Migrating back puts each week into its first day.
+	back, err := weekly.PlanMigration("daily")
+	if err != nil {
+		t.Fatalf("PlanMigration() back error = %v", err)
+	}
+	if len(back.Changes) != 2 || back.Changes[0].Filename != daily.getFilename(mon) {
+		t.Fatalf("back changes = %+v", back.Changes)
+	}
+	if got := string(back.Changes[0].New); got != "2024-08-26 Monday\n\n- [x] Review PR\n    - [x] Tests\n    - [ ] Docs\n- [x] Standup\n- [ ] Deploy\nNotes from Monday\n" {
+		t.Errorf("back content = %q", got)
+	}
+}
+
+func TestPlanMigrationMergesIntoExisting(t *testing.T) {
+	dir := t.TempDir()
+	daily := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	monthly := NewVault(Config{VaultLoc: dir, IntervalMode: "monthly"})
+	date := time.Date(2024, 8, 5, 0, 0, 0, 0, time.Local)
+
+	existing := writeTestFile(t, monthly, date, "# August\n\n- [ ] Budget\n")
+	writeTestFile(t, daily, date, "2024-08-05 Monday\n\n- [x] Budget\n- [ ] Invoice\n")
+
+	migration, err := monthly.PlanMigration("monthly")
+	if err != nil {
+		t.Fatalf("PlanMigration() error = %v", err)
+	}
+	if len(migration.Changes) != 1 || migration.Changes[0].Filename != existing {
+		t.Fatalf("changes = %+v", migration.Changes)
+	}
+	if got := string(migration.Changes[0].New); got != "# August\n\n- [x] Budget\n- [ ] Invoice\n" {
+		t.Errorf("New = %q", got)
+	}
+
+	// The plan is refused once a file it read has changed.
+	if err := os.WriteFile(existing, []byte("# August\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := monthly.ApplyMigration(migration); !errors.Is(err, ErrConflict) {
+		t.Errorf("ApplyMigration() error = %v, want ErrConflict", err)
+	}
+	if got := readTestFile(t, existing); got != "# August\n" {
+		t.Errorf("content after conflict = %q", got)
+	}
+}
+
+func TestPlanMigrationUnknownMode(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	if _, err := v.PlanMigration("yearly"); err == nil {
+		t.Error("PlanMigration(yearly) should fail")
+	}
+}
+
+func TestParsePeriodFileISOYear(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "weekly"})
+	// 2021-01-01 is in week 53 of ISO year 2020.
+	date := time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local)
+	file, ok := v.parsePeriodFile(v.getFilename(date))
+	if !ok || !file.date.Equal(date) || file.mode != "weekly" {
+		t.Errorf("parsePeriodFile(%s) = %+v, %v", v.getFilename(date), file, ok)
+	}
+	if _, ok := v.parsePeriodFile(dir + "/2024/August/notes.md"); ok {
+		t.Error("notes.md should not be a period file")
+	}
+}
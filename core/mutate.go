@@ -0,0 +1,242 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// editTask applies fn to the lines of the period file holding the task at
+// addr. fn receives the index of the task and returns the new lines.
+func (v *Vault) editTask(addr TaskAddress, fn func(lines []string, i int) ([]string, error)) error {
+	return v.updateFile(addr.Date, func(content []byte) ([]byte, error) {
+		lines := strings.Split(string(content), "\n")
+		i, err := addr.resolve(lines)
+		if err != nil {
+			return nil, err
+		}
+		lines, err = fn(lines, i)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.Join(lines, "\n")), nil
+	})
+}
+
+// RemoveTask deletes the task at addr together with its subtasks.
+func (v *Vault) RemoveTask(addr TaskAddress) error {
+	return v.editTask(addr, func(lines []string, i int) ([]string, error) {
+		end := subtreeEnd(lines, i)
+		return append(lines[:i:i], lines[end:]...), nil
+	})
+}
+
+// RenameTask replaces the text of the task at addr, keeping its
+// indentation and status.
+func (v *Vault) RenameTask(addr TaskAddress, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("task text cannot be empty")
+	}
+	return v.editTask(addr, func(lines []string, i int) ([]string, error) {
+		task, _ := ParseTask(lines[i], i+1)
+		task.setText(text)
+		lines[i] = task.String()
+		return lines, nil
+	})
+}
+
+// MoveTask moves the task at addr and its subtasks to the end of the period
+// file for date, as a top-level task. A new target file is not rolled over
+// into, as that would carry the task along with the move.
+func (v *Vault) MoveTask(addr TaskAddress, date time.Time) error {
+	source := v.getFilename(addr.Date)
+	target := v.getFilename(date)
+	if source == target {
+		return fmt.Errorf("task is already in the period of %s", date.Format(taskDateLayout))
+	}
+
+	return v.updateFiles([]time.Time{addr.Date, date}, func(contents [][]byte) ([][]byte, error) {
+		lines := strings.Split(string(contents[0]), "\n")
+		i, err := addr.resolve(lines)
+		if err != nil {
+			return nil, err
+		}
+		end := subtreeEnd(lines, i)
+		moved := dedent(lines[i:end], indentWidth(lines[i]))
+		lines = append(lines[:i:i], lines[end:]...)
+
+		return [][]byte{
+			[]byte(strings.Join(lines, "\n")),
+			appendLines(contents[1], moved),
+		}, nil
+	})
+}
+
+// dedent removes width columns of indentation from every line.
+func dedent(lines []string, width int) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		removed := 0
+		j := 0
+		for j < len(line) && removed < width && (line[j] == ' ' || line[j] == '\t') {
+			if line[j] == '\t' {
+				removed += 4
+			} else {
+				removed++
+			}
+			j++
+		}
+		out[i] = line[j:]
+	}
+	return out
+}
+
+// appendLines adds lines at the end of content, dropping trailing blank lines
+// from the moved block and making sure content ends with a newline first.
+func appendLines(content []byte, lines []string) []byte {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	for _, line := range lines {
+		content = append(content, line+"\n"...)
+	}
+	return content
+}
+
+// ShiftTask moves the task at addr, with its subtasks, above its previous
+// sibling (delta -1) or below its next sibling (delta 1).
+func (v *Vault) ShiftTask(addr TaskAddress, delta int) error {
+	return v.editTask(addr, func(lines []string, i int) ([]string, error) {
+		end := subtreeEnd(lines, i)
+		if delta < 0 {
+			prev := previousSibling(lines, i)
+			if prev < 0 {
+				return nil, fmt.Errorf("task is already first")
+			}
+			return swapBlocks(lines, prev, i, end), nil
+		}
+		next := nextSibling(lines, i)
+		if next < 0 {
+			return nil, fmt.Errorf("task is already last")
+		}
+		return swapBlocks(lines, i, next, subtreeEnd(lines, next)), nil
+	})
+}
+
+// IndentTask nests the task at addr, with its subtasks, under its previous sibling.
+func (v *Vault) IndentTask(addr TaskAddress) error {
+	return v.editTask(addr, func(lines []string, i int) ([]string, error) {
+		prev := previousSibling(lines, i)
+		if prev < 0 {
+			return nil, fmt.Errorf("task has no previous sibling to nest under")
+		}
+		unit := indentUnit(lines)
+		end := subtreeEnd(lines, i)
+		for j := i; j < end; j++ {
+			if strings.TrimSpace(lines[j]) != "" {
+				lines[j] = unit + lines[j]
+			}
+		}
+		return lines, nil
+	})
+}
+
+// OutdentTask moves the task at addr, with its subtasks, one level up. The
+// task stays where it is, so it becomes a sibling of its former parent.
+func (v *Vault) OutdentTask(addr TaskAddress) error {
+	return v.editTask(addr, func(lines []string, i int) ([]string, error) {
+		parent := parentTaskIndex(lines, i)
+		if parent < 0 {
+			return nil, fmt.Errorf("task is already at the top level")
+		}
+		end := subtreeEnd(lines, i)
+		parentEnd := subtreeEnd(lines, parent)
+		shift := indentWidth(lines[i]) - indentWidth(lines[parent])
+		block := dedent(lines[i:end], shift)
+		copy(lines[i:end], block)
+
+		// Later siblings would otherwise become children of the outdented task.
+		if end < parentEnd {
+			rest := append([]string(nil), lines[end:parentEnd]...)
+			moved := append([]string(nil), lines[i:end]...)
+			copy(lines[i:], rest)
+			copy(lines[i+len(rest):], moved)
+		}
+		return lines, nil
+	})
+}
+
+// previousSibling returns the index of the task before lines[i] at the same
+// indentation and under the same parent, or -1.
+func previousSibling(lines []string, i int) int {
+	width := indentWidth(lines[i])
+	for j := i - 1; j >= 0; j-- {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		w := indentWidth(lines[j])
+		if w < width {
+			return -1
+		}
+		if w == width {
+			if _, ok := ParseTask(lines[j], j+1); ok {
+				return j
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// nextSibling returns the index of the task after the subtree of lines[i]
+// at the same indentation, or -1.
+func nextSibling(lines []string, i int) int {
+	width := indentWidth(lines[i])
+	for j := subtreeEnd(lines, i); j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if indentWidth(lines[j]) != width {
+			return -1
+		}
+		if _, ok := ParseTask(lines[j], j+1); ok {
+			return j
+		}
+		return -1
+	}
+	return -1
+}
+
+// swapBlocks swaps lines[a:b] with lines[b:c].
+func swapBlocks(lines []string, a, b, c int) []string {
+	swapped := append([]string(nil), lines[b:c]...)
+	swapped = append(swapped, lines[a:b]...)
+	copy(lines[a:c], swapped)
+	return lines
+}
+
+// indentUnit guesses the indentation the file uses for one level: a tab if
+// tasks are indented with tabs, otherwise the smallest run of spaces.
+func indentUnit(lines []string) string {
+	smallest := 0
+	for i, line := range lines {
+		task, ok := ParseTask(line, i+1)
+		if !ok || task.Indent == "" {
+			continue
+		}
+		if strings.HasPrefix(task.Indent, "\t") {
+			return "\t"
+		}
+		if smallest == 0 || len(task.Indent) < smallest {
+			smallest = len(task.Indent)
+		}
+	}
+	if smallest == 0 {
+		smallest = 2
+	}
+	return strings.Repeat(" ", smallest)
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRemoveTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Task 1\n- [ ] Parent\n  - [ ] Child\n\n- [ ] Task 3\n")
+
+	task, _ := v.FindTask(testDate, "Parent", TaskFilter{})
+	if err := v.RemoveTask(task.Address(testDate)); err != nil {
+		t.Fatalf("RemoveTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] Task 1\n\n- [ ] Task 3\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
+
+func TestRenameTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Parent\n  - [x] Old name\n")
+
+	task, _ := v.FindTask(testDate, "Old name", TaskFilter{})
+	if err := v.RenameTask(task.Address(testDate), "New name #tag"); err != nil {
+		t.Fatalf("RenameTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] Parent\n  - [x] New name #tag\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	from := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)
+	source := writeTestFile(t, v, from, "- [ ] Stay\n- [ ] Parent\n  - [ ] Go along\n    - [ ] Deep\n- [ ] Also stay\n")
+	target := writeTestFile(t, v, to, "- [ ] Existing")
+
+	task, _ := v.FindTask(from, "Go along", TaskFilter{})
+	if err := v.MoveTask(task.Address(from), to); err != nil {
+		t.Fatalf("MoveTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, source), "- [ ] Stay\n- [ ] Parent\n- [ ] Also stay\n"; got != want {
+		t.Errorf("source content = %q, want %q", got, want)
+	}
+	if got, want := readTestFile(t, target), "- [ ] Existing\n- [ ] Go along\n  - [ ] Deep\n"; got != want {
+		t.Errorf("target content = %q, want %q", got, want)
+	}
+
+	if err := v.MoveTask(task.Address(from), to); err == nil {
+		t.Errorf("moving a task twice: error = nil, want a conflict")
+	}
+}
+
+func TestMoveTaskDoesNotRollOver(t *testing.T) {
+	for _, mode := range []string{"copy", "move"} {
+		v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily", Rollover: mode})
+		today := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+		tomorrow := today.AddDate(0, 0, 1)
+		source := writeTestFile(t, v, today, "- [ ] A\n- [ ] B\n")
+
+		task, _ := v.FindTask(today, "A", TaskFilter{})
+		if err := v.MoveTask(task.Address(today), tomorrow); err != nil {
+			t.Fatalf("%s: MoveTask() error = %v", mode, err)
+		}
+		if got, want := readTestFile(t, source), "- [ ] B\n"; got != want {
+			t.Errorf("%s: source content = %q, want %q", mode, got, want)
+		}
+		if got, want := readTestFile(t, v.getFilename(tomorrow)), "2024-08-31 Saturday\n\n- [ ] A\n"; got != want {
+			t.Errorf("%s: target content = %q, want %q", mode, got, want)
+		}
+	}
+}
+
+func TestShiftTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] A\n  - [ ] A1\n- [ ] B\n  - [ ] B1\n  - [ ] B2\n")
+
+	task, _ := v.FindTask(testDate, "B", TaskFilter{})
+	if err := v.ShiftTask(task.Address(testDate), -1); err != nil {
+		t.Fatalf("ShiftTask(-1) error = %v", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] B\n  - [ ] B1\n  - [ ] B2\n- [ ] A\n  - [ ] A1\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+
+	task, _ = v.FindTask(testDate, "B1", TaskFilter{})
+	if err := v.ShiftTask(task.Address(testDate), 1); err != nil {
+		t.Fatalf("ShiftTask(1) error = %v", err)
+	}
+	task, _ = v.FindTask(testDate, "B1", TaskFilter{})
+	if err := v.ShiftTask(task.Address(testDate), 1); err == nil {
+		t.Errorf("ShiftTask(1) on last child error = nil")
+	}
+	if got, want := readTestFile(t, filename), "- [ ] B\n  - [ ] B2\n  - [ ] B1\n- [ ] A\n  - [ ] A1\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
+
+func TestIndentAndOutdentTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] A\n    - [ ] A1\n- [ ] B\n    - [ ] B1\n")
+
+	task, _ := v.FindTask(testDate, "B", TaskFilter{})
+	if err := v.IndentTask(task.Address(testDate)); err != nil {
+		t.Fatalf("IndentTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] A\n    - [ ] A1\n    - [ ] B\n        - [ ] B1\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+
+	task, _ = v.FindTask(testDate, "A1", TaskFilter{})
+	if err := v.OutdentTask(task.Address(testDate)); err != nil {
+		t.Fatalf("OutdentTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] A\n    - [ ] B\n        - [ ] B1\n- [ ] A1\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+
+	task, _ = v.FindTask(testDate, "A1", TaskFilter{})
+	if err := v.OutdentTask(task.Address(testDate)); err == nil {
+		t.Errorf("OutdentTask() on top-level task error = nil")
+	}
+}
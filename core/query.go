@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskFilter selects tasks. The zero value matches every task.
+type TaskFilter struct {
+	Status string   // "done", "open" or "" for both
+	Tags   []string // tags the task must all have, with or without #
+	Text   string   // case-insensitive substring of the task text
+}
+
+func (f TaskFilter) Match(t Task) bool {
+	switch f.Status {
+	case "done":
+		if !t.Selected {
+			return false
+		}
+	case "open":
+		if t.Selected {
+			return false
+		}
+	}
+	for _, tag := range f.Tags {
+		if !t.HasTag(tag) {
+			return false
+		}
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(t.Text), strings.ToLower(f.Text)) {
+		return false
+	}
+	return true
+}
+
+// describeMismatch says why t does not match the filter, for errors.
+func (f TaskFilter) describeMismatch(t Task) string {
+	switch {
+	case f.Status == "open" && t.Selected:
+		return "is already done"
+	case f.Status == "done" && !t.Selected:
+		return "is not done"
+	}
+	return "does not match the filter"
+}
+
+// PeriodTasks are the tasks of one period file.
+type PeriodTasks struct {
+	Date     time.Time // the first day of the queried range within the period
+	Filename string
+	Tasks    []Task
+}
+
+// Periods returns one date for every period overlapping from..to, in order.
+func (v *Vault) Periods(from, to time.Time) []time.Time {
+	var dates []time.Time
+	last := ""
+	from = truncateDay(from)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if filename := v.getFilename(day); filename != last {
+			dates = append(dates, day)
+			last = filename
+		}
+	}
+	return dates
+}
+
+// ListTasks returns the tasks matching filter in every existing period file
+// between from and to. Periods without a file are skipped.
+func (v *Vault) ListTasks(from, to time.Time, filter TaskFilter) ([]PeriodTasks, error) {
+	if err := checkRange(from, to); err != nil {
+		return nil, err
+	}
+	var result []PeriodTasks
+	for _, date := range v.Periods(from, to) {
+		filename := v.getFilename(date)
+		if !fileExists(filename) {
+			continue
+		}
+		tasks, err := v.linesWithSelection(date)
+		if err != nil {
+			return result, err
+		}
+		period := PeriodTasks{Date: date, Filename: filename}
+		for _, task := range tasks {
+			if filter.Match(task) {
+				period.Tasks = append(period.Tasks, task)
+			}
+		}
+		result = append(result, period)
+	}
+	return result, nil
+}
+
+// checkRange rejects a range of days that ends before it starts.
+func checkRange(from, to time.Time) error {
+	if truncateDay(to).Before(truncateDay(from)) {
+		return fmt.Errorf("the range ends before it starts")
+	}
+	return nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Progress returns how many of the period's tasks are done out of how many there are.
+func (p PeriodTasks) Progress() (done, total int) {
+	for _, task := range p.Tasks {
+		if task.Selected {
+			done++
+		}
+	}
+	return done, len(p.Tasks)
+}
+
+// Zoom levels for views spanning several period files.
+const (
+	ZoomDay   = "day"
+	ZoomWeek  = "week"
+	ZoomMonth = "month"
+)
+
+// ViewRange returns the first and last day of the day, ISO week (Monday to
+// Sunday) or month containing date.
+func ViewRange(zoom string, date time.Time) (time.Time, time.Time) {
+	day := truncateDay(date)
+	switch zoom {
+	case ZoomWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6)
+	case ZoomMonth:
+		start := day.AddDate(0, 0, 1-day.Day())
+		return start, start.AddDate(0, 1, -1)
+	}
+	return day, day
+}
+
+// ShiftView moves date by one day, week or month.
+func ShiftView(zoom string, date time.Time, delta int) time.Time {
+	switch zoom {
+	case ZoomWeek:
+		return date.AddDate(0, 0, 7*delta)
+	case ZoomMonth:
+		start, _ := ViewRange(ZoomMonth, date)
+		return start.AddDate(0, delta, 0)
+	}
+	return date.AddDate(0, 0, delta)
+}
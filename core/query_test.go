@@ -0,0 +1,78 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriods(t *testing.T) {
+	from := time.Date(2024, 8, 28, 0, 0, 0, 0, time.UTC) // Wednesday, week 35
+	to := time.Date(2024, 9, 10, 0, 0, 0, 0, time.UTC)   // Tuesday, week 37
+
+	tests := []struct {
+		intervalMode string
+		want         int
+	}{
+		{"daily", 14},
+		{"weekly", 4}, // week 35, then week 36 split across August and September, then week 37
+		{"monthly", 2},
+	}
+	for _, tt := range tests {
+		v := NewVault(Config{VaultLoc: "/vault", IntervalMode: tt.intervalMode})
+		if got := v.Periods(from, to); len(got) != tt.want {
+			t.Errorf("%s: Periods() returned %d dates, want %d", tt.intervalMode, len(got), tt.want)
+		}
+	}
+}
+
+func TestListTasks(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	day1 := time.Date(2024, 8, 29, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	writeTestFile(t, v, day1, "- [x] Write report #work\n- [ ] Call mom #home\n")
+	writeTestFile(t, v, day2, "- [ ] Review report #work\n")
+
+	if _, err := v.ListTasks(day2, day1, TaskFilter{}); err == nil {
+		t.Errorf("ListTasks() with from after to error = nil")
+	}
+
+	periods, err := v.ListTasks(day1, day2.AddDate(0, 0, 3), TaskFilter{Tags: []string{"#work"}})
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if len(periods) != 2 {
+		t.Fatalf("got %d periods, want 2 (missing files are skipped)", len(periods))
+	}
+	if len(periods[0].Tasks) != 1 || periods[0].Tasks[0].Text != "Write report #work" {
+		t.Errorf("periods[0].Tasks = %+v", periods[0].Tasks)
+	}
+
+	periods, _ = v.ListTasks(day1, day2, TaskFilter{Status: "open", Text: "REPORT"})
+	if len(periods[0].Tasks) != 0 || len(periods[1].Tasks) != 1 {
+		t.Errorf("open tasks containing report: %+v", periods)
+	}
+}
+
+func TestViewRange(t *testing.T) {
+	date := time.Date(2024, 8, 30, 15, 0, 0, 0, time.UTC) // Friday
+	tests := []struct {
+		zoom     string
+		from, to time.Time
+	}{
+		{ZoomDay, time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC), time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)},
+		{ZoomWeek, time.Date(2024, 8, 26, 0, 0, 0, 0, time.UTC), time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{ZoomMonth, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		from, to := ViewRange(tt.zoom, date)
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("ViewRange(%s) = %v..%v, want %v..%v", tt.zoom, from, to, tt.from, tt.to)
+		}
+	}
+
+	// Shifting a month from the 31st lands in the next month, not the one after.
+	jan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := ShiftView(ZoomMonth, jan31, 1); got.Month() != time.February {
+		t.Errorf("ShiftView(month, Jan 31, 1) = %v, want February", got)
+	}
+}
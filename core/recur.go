@@ -0,0 +1,402 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ErrNoRecurringTask is returned when removing a rule that does not exist.
+var ErrNoRecurringTask = errors.New("no such recurring task")
+
+// RecurringTask is a rule that adds Text to every period it is due in.
+type RecurringTask struct {
+	ID    int    `toml:"id"`
+	Text  string `toml:"text"`
+	Rule  string `toml:"rule"`  // e.g. "every weekday", see ParseRecurrence
+	Start string `toml:"start"` // YYYY-MM-DD, the first day it can be due
+}
+
+// Recurrence is a parsed recurrence rule.
+type Recurrence struct {
+	weekdays  []time.Weekday // due on these days of the week
+	monthDays []int          // due on these days of the month, -1 is the last
+	interval  int            // due every interval units after the start...
+	unit      string         // "day", "week" or "month"
+	// ...or, with afterCompletion, that long after the last instance was done.
+	afterCompletion bool
+}
+
+var (
+	intervalRulePattern = regexp.MustCompile(`^every (\d+) (day|week|month)s?( after completion)?$`)
+	monthlyRulePattern  = regexp.MustCompile(`^(?:monthly|every month) on (?:the )?(.+)$`)
+	ordinalPattern      = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWeekday accepts full and abbreviated English weekday names.
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return 0, false
+	}
+	day, ok := weekdayNames[name[:3]]
+	if !ok || !strings.HasPrefix(strings.ToLower(day.String()), name) {
+		return 0, false
+	}
+	return day, true
+}
+
+// ParseRecurrence parses a rule such as:
+//
+//	every day, daily
+//	every weekday
+//	every monday, every mon,thu
+//	monthly on the 1st, every month on the 1st and 15th, monthly on the last
+//	every 2 weeks, every 3 days after completion
+//
+// Rules counting days, weeks or months are counted from the start day.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.Join(strings.Fields(strings.ToLower(rule)), " ")
+
+	switch rule {
+	case "every day", "daily":
+		return Recurrence{interval: 1, unit: "day"}, nil
+	case "every weekday":
+		return Recurrence{weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}, nil
+	case "every week", "weekly":
+		return Recurrence{interval: 1, unit: "week"}, nil
+	case "every month", "monthly":
+		return Recurrence{interval: 1, unit: "month"}, nil
+	}
+
+	if m := intervalRulePattern.FindStringSubmatch(rule); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n == 0 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence %q: the interval must be at least 1", rule)
+		}
+		return Recurrence{interval: n, unit: m[2], afterCompletion: m[3] != ""}, nil
+	}
+
+	if m := monthlyRulePattern.FindStringSubmatch(rule); m != nil {
+		var r Recurrence
+		for _, field := range strings.FieldsFunc(m[1], func(c rune) bool { return c == ',' || c == ' ' }) {
+			if field == "and" || field == "the" {
+				continue
+			}
+			if field == "last" {
+				r.monthDays = append(r.monthDays, -1)
+				continue
+			}
+			d := ordinalPattern.FindStringSubmatch(field)
+			if d == nil {
+				return Recurrence{}, fmt.Errorf("invalid recurrence %q: %q is not a day of the month", rule, field)
+			}
+			day, _ := strconv.Atoi(d[1])
+			if day < 1 || day > 31 {
+				return Recurrence{}, fmt.Errorf("invalid recurrence %q: %q is not a day of the month", rule, field)
+			}
+			r.monthDays = append(r.monthDays, day)
+		}
+		if len(r.monthDays) == 0 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence %q: no day of the month", rule)
+		}
+		return r, nil
+	}
+
+	if strings.HasPrefix(rule, "every ") {
+		var r Recurrence
+		for _, name := range strings.FieldsFunc(strings.TrimPrefix(rule, "every "), func(c rune) bool { return c == ',' || c == ' ' }) {
+			if name == "and" {
+				continue
+			}
+			day, ok := parseWeekday(name)
+			if !ok {
+				return Recurrence{}, fmt.Errorf("invalid recurrence %q: unknown weekday %q", rule, name)
+			}
+			r.weekdays = append(r.weekdays, day)
+		}
+		if len(r.weekdays) > 0 {
+			return r, nil
+		}
+	}
+	return Recurrence{}, fmt.Errorf("invalid recurrence %q", rule)
+}
+
+// dueOn reports whether a calendar rule falls on day. Rules that depend on
+// when the last instance was completed are never due on a fixed day.
+func (r Recurrence) dueOn(day, start time.Time) bool {
+	day, start = truncateDay(day), truncateDay(start)
+	if day.Before(start) || r.afterCompletion {
+		return false
+	}
+	for _, weekday := range r.weekdays {
+		if day.Weekday() == weekday {
+			return true
+		}
+	}
+	last := day.AddDate(0, 1, -day.Day()).Day()
+	for _, monthDay := range r.monthDays {
+		if monthDay == day.Day() || (monthDay == -1 && day.Day() == last) || (monthDay > last && day.Day() == last) {
+			return true
+		}
+	}
+	switch r.unit {
+	case "day":
+		return daysBetween(start, day)%r.interval == 0
+	case "week":
+		days := daysBetween(start, day)
+		return days%7 == 0 && (days/7)%r.interval == 0
+	case "month":
+		// A start on the 29th to 31st falls on the last day of shorter months.
+		months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+		return months%r.interval == 0 && (day.Day() == start.Day() || (start.Day() > last && day.Day() == last))
+	}
+	return false
+}
+
+// next returns the first day an after-completion rule is due again after an
+// instance was done on day.
+func (r Recurrence) next(day time.Time) time.Time {
+	switch r.unit {
+	case "week":
+		return day.AddDate(0, 0, 7*r.interval)
+	case "month":
+		return day.AddDate(0, r.interval, 0)
+	}
+	return day.AddDate(0, 0, r.interval)
+}
+
+// daysBetween counts calendar days from a to b, ignoring DST shifts.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+// recurringFile is the sidecar file holding the vault's recurring tasks.
+func (v *Vault) recurringFile() string {
+	return filepath.Join(v.config.VaultLoc, "recurring.toml")
+}
+
+type recurringTasks struct {
+	Tasks []RecurringTask `toml:"task"`
+}
+
+// RecurringTasks returns the vault's recurring task rules.
+func (v *Vault) RecurringTasks() ([]RecurringTask, error) {
+	var file recurringTasks
+	if !fileExists(v.recurringFile()) {
+		return nil, nil
+	}
+	if _, err := toml.DecodeFile(v.recurringFile(), &file); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", v.recurringFile(), err)
+	}
+	return file.Tasks, nil
+}
+
+// updateRecurring applies fn to the recurring tasks under the file's lock.
+func (v *Vault) updateRecurring(fn func(tasks []RecurringTask) ([]RecurringTask, error)) error {
+	filename := v.recurringFile()
+	return v.withLock(filename, func() error {
+		old, err := os.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+		var file recurringTasks
+		if _, err := toml.Decode(string(old), &file); err != nil {
+			return fmt.Errorf("error reading %s: %w", filename, err)
+		}
+
+		if file.Tasks, err = fn(file.Tasks); err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		if err := toml.NewEncoder(&out).Encode(file); err != nil {
+			return err
+		}
+		return replaceFile(filename, old, out.Bytes())
+	})
+}
+
+// AddRecurring stores a new rule adding text to every period it is due in,
+// starting on start.
+func (v *Vault) AddRecurring(text, rule string, start time.Time) (RecurringTask, error) {
+	if _, err := ParseRecurrence(rule); err != nil {
+		return RecurringTask{}, err
+	}
+	task := RecurringTask{Text: strings.TrimSpace(text), Rule: rule, Start: start.Format(taskDateLayout)}
+	if task.Text == "" {
+		return RecurringTask{}, fmt.Errorf("recurring task has no text")
+	}
+	err := v.updateRecurring(func(tasks []RecurringTask) ([]RecurringTask, error) {
+		for _, t := range tasks {
+			if t.ID >= task.ID {
+				task.ID = t.ID + 1
+			}
+		}
+		if task.ID == 0 {
+			task.ID = 1
+		}
+		return append(tasks, task), nil
+	})
+	return task, err
+}
+
+// RemoveRecurring deletes the rule with id. Instances already added to
+// period files are left alone.
+func (v *Vault) RemoveRecurring(id int) (RecurringTask, error) {
+	var removed RecurringTask
+	err := v.updateRecurring(func(tasks []RecurringTask) ([]RecurringTask, error) {
+		for i, t := range tasks {
+			if t.ID == id {
+				removed = t
+				return append(tasks[:i], tasks[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %d", ErrNoRecurringTask, id)
+	})
+	return removed, err
+}
+
+// dueRecurring returns the recurring tasks due in the period of date. An
+// instance is recognised by its text, ignoring carry annotations, so that a
+// task carried into the period is not added a second time. Rules due after
+// completion need the index, which is only read if completions is set.
+func (v *Vault) dueRecurring(date time.Time, existing []Task, completions bool) ([]RecurringTask, error) {
+	rules, err := v.RecurringTasks()
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	present := map[string]bool{}
+	for _, task := range existing {
+		present[carryKey(task.Text)] = true
+	}
+
+	start, end := v.periodBounds(date)
+	var due []RecurringTask
+	// The index is only read for rules due after completion, and then once.
+	var files []IndexedFile
+	indexed := false
+	for _, rule := range rules {
+		if present[carryKey(rule.Text)] {
+			continue
+		}
+		r, err := ParseRecurrence(rule.Rule)
+		if err != nil {
+			return nil, err
+		}
+		first, err := time.ParseInLocation(taskDateLayout, rule.Start, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of recurring task %d: %w", rule.ID, err)
+		}
+
+		isDue := false
+		if r.afterCompletion {
+			if !completions {
+				continue
+			}
+			if !indexed {
+				if files, err = v.Index(); err != nil {
+					return nil, err
+				}
+				indexed = true
+			}
+			isDue = v.dueAfterCompletion(files, r, rule, first, start, end)
+		} else {
+			for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+				if r.dueOn(day, first) {
+					isDue = true
+					break
+				}
+			}
+		}
+		if isDue {
+			due = append(due, rule)
+		}
+	}
+	return due, nil
+}
+
+// dueAfterCompletion looks for the last instance of rule in the indexed
+// files before the period from start to end. The rule is due if there is
+// none yet, or if it was done long enough ago; an open instance is still
+// pending, so it is not.
+func (v *Vault) dueAfterCompletion(files []IndexedFile, r Recurrence, rule RecurringTask, first, start, end time.Time) bool {
+	if end.Before(first) {
+		return false
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		if file.Mode != v.config.IntervalMode || file.Date.Before(first) || !file.Date.Before(start) {
+			continue
+		}
+		for _, task := range file.Tasks {
+			if carryKey(task.Text) != carryKey(rule.Text) {
+				continue
+			}
+			if !task.Selected {
+				return false
+			}
+			_, doneEnd := v.periodBounds(file.Date)
+			return !r.next(doneEnd).After(end)
+		}
+	}
+	return true
+}
+
+// recurringLines returns the lines to add to content, the period file for
+// date, for the recurring tasks due in it, see dueRecurring.
+func (v *Vault) recurringLines(date time.Time, content []byte, completions bool) ([]string, error) {
+	var existing []Task
+	for i, line := range strings.Split(string(content), "\n") {
+		if task, ok := ParseTask(line, i+1); ok {
+			existing = append(existing, task)
+		}
+	}
+	due, err := v.dueRecurring(date, existing, completions)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, rule := range due {
+		lines = append(lines, NewTask(rule.Text).String())
+	}
+	return lines, nil
+}
+
+// ApplyRecurring adds the recurring tasks due in the period of date that
+// are not in its file yet. New period files get them when they are created;
+// this catches up a file that already existed when a rule was added, and is
+// only run when asked for, so removed instances stay removed. It returns the
+// added tasks.
+func (v *Vault) ApplyRecurring(date time.Time) ([]Task, error) {
+	if !fileExists(v.getFilename(date)) {
+		return nil, nil
+	}
+	var added []Task
+	err := v.updateFile(date, func(content []byte) ([]byte, error) {
+		lines, err := v.recurringLines(date, content, true)
+		if err != nil || len(lines) == 0 {
+			return content, err
+		}
+		for _, line := range lines {
+			task, _ := ParseTask(line, 0)
+			added = append(added, task)
+		}
+		return appendLines(content, lines), nil
+	})
+	return added, err
+}
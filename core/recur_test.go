@@ -0,0 +1,177 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	start := time.Date(2024, 8, 1, 0, 0, 0, 0, time.Local) // a Thursday
+	day := func(d int) time.Time { return start.AddDate(0, 0, d-1) }
+
+	tests := []struct {
+		rule string
+		due  []int // days of August 2024 in 1..14 the rule is due on
+	}{
+		{"every day", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}},
+		{"every weekday", []int{1, 2, 5, 6, 7, 8, 9, 12, 13, 14}},
+		{"every Monday", []int{5, 12}},
+		{"every tue, thursday", []int{1, 6, 8, 13}},
+		{"every 3 days", []int{1, 4, 7, 10, 13}},
+		{"every 2 weeks", []int{1}},
+		{"weekly", []int{1, 8}},
+		{"monthly on the 1st and 10th", []int{1, 10}},
+		{"every 3 days after completion", nil},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error = %v", tt.rule, err)
+		}
+		var due []int
+		for d := 1; d <= 14; d++ {
+			if r.dueOn(day(d), start) {
+				due = append(due, d)
+			}
+		}
+		if len(due) != len(tt.due) {
+			t.Errorf("%q is due on %v, want %v", tt.rule, due, tt.due)
+			continue
+		}
+		for i := range due {
+			if due[i] != tt.due[i] {
+				t.Errorf("%q is due on %v, want %v", tt.rule, due, tt.due)
+				break
+			}
+		}
+	}
+
+	last, _ := ParseRecurrence("monthly on the last")
+	if !last.dueOn(time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local), start.AddDate(-1, 0, 0)) {
+		t.Error("monthly on the last should be due on 2024-02-29")
+	}
+
+	monthly, _ := ParseRecurrence("every month")
+	jan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)
+	for _, day := range []time.Time{jan31, time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local), time.Date(2024, 4, 30, 0, 0, 0, 0, time.Local), time.Date(2024, 5, 31, 0, 0, 0, 0, time.Local)} {
+		if !monthly.dueOn(day, jan31) {
+			t.Errorf("every month from 2024-01-31 should be due on %s", day.Format(taskDateLayout))
+		}
+	}
+	if monthly.dueOn(time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), jan31) || monthly.dueOn(time.Date(2024, 5, 30, 0, 0, 0, 0, time.Local), jan31) {
+		t.Error("every month from 2024-01-31 should only be due on the last day of shorter months")
+	}
+
+	for _, rule := range []string{"", "every", "every funday", "monthly on the 32nd", "every 0 days", "sometimes"} {
+		if _, err := ParseRecurrence(rule); err == nil {
+			t.Errorf("ParseRecurrence(%q) should fail", rule)
+		}
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	monday := time.Date(2024, 8, 26, 0, 0, 0, 0, time.Local)
+
+	standup, err := v.AddRecurring("Standup", "every weekday", monday)
+	if err != nil {
+		t.Fatalf("AddRecurring() error = %v", err)
+	}
+	review, _ := v.AddRecurring("Weekly review", "every fri", monday)
+	if standup.ID != 1 || review.ID != 2 {
+		t.Errorf("IDs = %d, %d; want 1, 2", standup.ID, review.ID)
+	}
+	if _, err := v.AddRecurring("Broken", "every so often", monday); err == nil {
+		t.Error("AddRecurring() with an invalid rule should fail")
+	}
+
+	// New period files get the instances due in them.
+	if err := v.AddTask(monday, "Other"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestFile(t, v.getFilename(monday)), "2024-08-26 Monday\n\n- [ ] Standup\n- [ ] Other\n"; got != want {
+		t.Errorf("new file = %q, want %q", got, want)
+	}
+	friday := monday.AddDate(0, 0, 4)
+	tasks, _ := v.LoadLinesWithSelection(friday)
+	if len(tasks) != 2 || tasks[0].Text != "Standup" || tasks[1].Text != "Weekly review" {
+		t.Errorf("Friday tasks = %v", tasks)
+	}
+	if tasks, _ := v.LoadLinesWithSelection(monday.AddDate(0, 0, 5)); len(tasks) != 0 {
+		t.Errorf("Saturday tasks = %v, want none", tasks)
+	}
+
+	// Existing files catch up on rules added later, once.
+	writeTestFile(t, v, friday, "- [ ] Weekly review carried:1 from:2024-08-29\n")
+	added, err := v.ApplyRecurring(friday)
+	if err != nil || len(added) != 1 || added[0].Text != "Standup" {
+		t.Errorf("ApplyRecurring() = %v, %v; want Standup", added, err)
+	}
+	if added, _ := v.ApplyRecurring(friday); len(added) != 0 {
+		t.Errorf("second ApplyRecurring() = %v, want nothing", added)
+	}
+
+	// Opening an existing file does not bring back an instance removed from it.
+	writeTestFile(t, v, friday, "- [ ] Weekly review\n")
+	os.Setenv("TD_TEST_MODE", "true")
+	defer os.Unsetenv("TD_TEST_MODE")
+	if err := v.OpenEditor(friday, 1, false); err != nil {
+		t.Fatalf("OpenEditor() error = %v", err)
+	}
+	if got, want := readTestFile(t, v.getFilename(friday)), "- [ ] Weekly review\n"; got != want {
+		t.Errorf("OpenEditor() file = %q, want %q", got, want)
+	}
+
+	if _, err := v.RemoveRecurring(1); err != nil {
+		t.Fatalf("RemoveRecurring() error = %v", err)
+	}
+	if _, err := v.RemoveRecurring(1); !errors.Is(err, ErrNoRecurringTask) {
+		t.Errorf("RemoveRecurring() of a missing rule error = %v", err)
+	}
+	rules, _ := v.RecurringTasks()
+	if len(rules) != 1 || rules[0].Text != "Weekly review" {
+		t.Errorf("RecurringTasks() = %v", rules)
+	}
+}
+
+func TestRecurringAfterCompletion(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	start := time.Date(2024, 8, 1, 0, 0, 0, 0, time.Local)
+	if _, err := v.AddRecurring("Water plants", "every 3 days after completion", start); err != nil {
+		t.Fatal(err)
+	}
+	due := func(d int) bool {
+		t.Helper()
+		rules, err := v.dueRecurring(start.AddDate(0, 0, d-1), nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(rules) == 1
+	}
+
+	// Looking at a period without a file does not read the vault for it...
+	if tasks, _ := v.LoadLinesWithSelection(start); len(tasks) != 0 || fileExists(v.indexFile()) {
+		t.Errorf("preview = %v, want no tasks and no index", tasks)
+	}
+	// ...but creating the file does.
+	if err := v.AddTask(start, "Other"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestFile(t, v.getFilename(start)), "2024-08-01 Thursday\n\n- [ ] Water plants\n- [ ] Other\n"; got != want {
+		t.Errorf("new file = %q, want %q", got, want)
+	}
+
+	if !due(1) {
+		t.Error("not due on the start day")
+	}
+	writeTestFile(t, v, start, "- [ ] Water plants\n")
+	if due(2) {
+		t.Error("due while the last instance is open")
+	}
+	writeTestFile(t, v, start.AddDate(0, 0, 1), "- [x] Water plants carried:1 from:2024-08-01\n")
+	if due(4) || !due(5) {
+		t.Error("done on the 2nd, want it due again from the 5th")
+	}
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// PeriodReport counts what happened to the tasks of one period file.
+type PeriodReport struct {
+	Date      time.Time // the first day of the period
+	Filename  string
+	Created   int // tasks that were not carried in
+	Completed int // tasks done in this period
+	CarriedIn int // tasks still open in an earlier period
+	Open      int // tasks left open in this period
+}
+
+// OpenTask is a task that is still open in the latest period it is in.
+type OpenTask struct {
+	Task    Task
+	Date    time.Time // the latest period it is in
+	Since   time.Time // the period it was created in
+	Periods int       // how many periods it has been in since
+}
+
+// Age is how many days the task has been open on today.
+func (t OpenTask) Age(today time.Time) int {
+	return daysBetween(t.Since, today)
+}
+
+// Report sums up how the tasks of the vault were got through.
+type Report struct {
+	Periods []PeriodReport // the periods in the range, oldest first
+
+	Created, Completed, CarriedIn int // totals over Periods
+
+	// Oldest are the open tasks that have been open the longest, oldest
+	// first, over the whole vault.
+	Oldest []OpenTask
+	// Streaks of consecutive periods in the vault's layout in which every
+	// task was done. Periods without tasks are skipped, and the current
+	// period only counts once it is complete.
+	CurrentStreak, LongestStreak int
+}
+
+// Report analyses every period file of the vault, recognising a task across
+// periods by its text without carry and focus annotations. Periods with a
+// day from from to to are listed; at most oldest open tasks are returned.
+func (v *Vault) Report(from, to, today time.Time, oldest int) (Report, error) {
+	files, err := v.Index()
+	if err != nil {
+		return Report{}, err
+	}
+	return v.buildReport(files, from, to, today, oldest), nil
+}
+
+func (v *Vault) buildReport(files []IndexedFile, from, to, today time.Time, oldest int) Report {
+	from, to, today = truncateDay(from), truncateDay(to), truncateDay(today)
+
+	type history struct {
+		first, last time.Time
+		latest      Task
+		periods     int
+		done        bool
+	}
+	seen := map[string]*history{}
+	var keys []string
+	var report Report
+
+	for _, file := range files {
+		period := PeriodReport{Date: file.Date, Filename: file.Filename}
+		counted := map[string]bool{} // a task listed twice in one file counts once
+		for _, task := range file.Tasks {
+			key := carryKey(task.Text)
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+
+			h := seen[key]
+			if h == nil {
+				h = &history{}
+				seen[key] = h
+				keys = append(keys, key)
+			}
+			if h.periods > 0 && !h.done {
+				period.CarriedIn++
+			} else {
+				// New, or done before and due again, like a recurring task.
+				period.Created++
+				h.first, h.periods = file.Date, 0
+			}
+			h.periods++
+			h.last, h.latest, h.done = file.Date, task, task.Selected
+
+			if task.Selected {
+				period.Completed++
+			} else {
+				period.Open++
+			}
+		}
+
+		if v.overlaps(file, from, to) {
+			report.Periods = append(report.Periods, period)
+			report.Created += period.Created
+			report.Completed += period.Completed
+			report.CarriedIn += period.CarriedIn
+		}
+	}
+
+	for _, key := range keys {
+		h := seen[key]
+		if h.done {
+			continue
+		}
+		report.Oldest = append(report.Oldest, OpenTask{Task: h.latest, Date: h.last, Since: h.first, Periods: h.periods})
+	}
+	sort.SliceStable(report.Oldest, func(i, j int) bool {
+		return report.Oldest[i].Since.Before(report.Oldest[j].Since)
+	})
+	if len(report.Oldest) > oldest {
+		report.Oldest = report.Oldest[:oldest]
+	}
+
+	report.CurrentStreak, report.LongestStreak = v.streaks(files, today)
+	return report
+}
+
+// streaks returns the current and longest runs of consecutive periods, in
+// the vault's layout, with every task done.
+func (v *Vault) streaks(files []IndexedFile, today time.Time) (current, longest int) {
+	done := map[string]bool{} // by filename, for periods with tasks
+	for _, file := range files {
+		if file.Mode != v.config.IntervalMode || len(file.Tasks) == 0 {
+			continue
+		}
+		all := true
+		for _, task := range file.Tasks {
+			all = all && task.Selected
+		}
+		done[file.Filename] = all
+	}
+	if len(done) == 0 {
+		return 0, 0
+	}
+
+	first := today
+	for _, file := range files {
+		if _, ok := done[file.Filename]; ok && file.Date.Before(first) {
+			first = file.Date
+		}
+	}
+	run := 0
+	for _, date := range v.Periods(first, today) {
+		filename := v.getFilename(date)
+		all, ok := done[filename]
+		switch {
+		case !ok:
+			// Nothing to do that period: neither extends nor breaks a streak.
+		case all:
+			run++
+			if run > longest {
+				longest = run
+			}
+		case filename == v.getFilename(today):
+			// The current period is still in progress.
+		default:
+			run = 0
+		}
+	}
+	return run, longest
+}
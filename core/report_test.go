@@ -0,0 +1,66 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	day := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+
+	writeTestFile(t, v, day.AddDate(0, 0, -4), "- [x] Standup\n- [ ] Write report\n- [ ] Old idea\n")
+	writeTestFile(t, v, day.AddDate(0, 0, -3), "- [x] Standup\n- [x] Write report carried:1 from:2024-08-26\n")
+	writeTestFile(t, v, day.AddDate(0, 0, -2), "- [x] Review\n")
+	// Nothing on the 29th, which neither extends nor breaks the streak.
+	writeTestFile(t, v, day, "- [x] Review again\n- [ ] Plan 🍅1 focus:25m\n")
+
+	report, err := v.Report(day.AddDate(0, 0, -3), day, day, 10)
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	if len(report.Periods) != 3 {
+		t.Fatalf("Periods = %+v", report.Periods)
+	}
+	carried := report.Periods[0]
+	if carried.Created != 1 || carried.Completed != 2 || carried.CarriedIn != 1 || carried.Open != 0 {
+		t.Errorf("Periods[0] = %+v", carried)
+	}
+	if report.Created != 4 || report.Completed != 4 || report.CarriedIn != 1 {
+		t.Errorf("totals: created %d, completed %d, carried %d", report.Created, report.Completed, report.CarriedIn)
+	}
+
+	if len(report.Oldest) != 2 || report.Oldest[0].Task.Text != "Old idea" || report.Oldest[1].Task.Text != "Plan 🍅1 focus:25m" {
+		t.Fatalf("Oldest = %+v", report.Oldest)
+	}
+	if age := report.Oldest[0].Age(day); age != 4 {
+		t.Errorf("Age() = %d, want 4", age)
+	}
+
+	// The 26th has an open task; the 27th and 28th are done and today is
+	// still in progress.
+	if report.CurrentStreak != 2 || report.LongestStreak != 2 {
+		t.Errorf("streaks = %d, %d, want 2, 2", report.CurrentStreak, report.LongestStreak)
+	}
+
+	if report, _ := v.Report(day, day, day, 1); len(report.Oldest) != 1 {
+		t.Errorf("Report() with oldest 1 returned %d tasks", len(report.Oldest))
+	}
+}
+
+func TestReportWeekStartingBeforeRange(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "weekly"})
+	monday := time.Date(2024, 8, 26, 0, 0, 0, 0, time.Local)
+	writeTestFile(t, v, monday, "- [x] Review\n")
+
+	thursday := monday.AddDate(0, 0, 3)
+	report, err := v.Report(thursday, thursday, thursday, 10)
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if len(report.Periods) != 1 || report.Completed != 1 {
+		t.Errorf("Report() from the middle of the week = %+v, want the week", report)
+	}
+}
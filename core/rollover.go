@@ -0,0 +1,157 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RolloverOptions controls how open tasks are carried into a new period.
+type RolloverOptions struct {
+	// Move removes carried tasks from the previous period instead of copying
+	// them. Tasks with completed subtasks are always copied so that the
+	// previous period keeps its history.
+	Move bool
+	// Annotate marks carried tasks with the period they came from and how
+	// many times they have been carried, as "carried:N from:YYYY-MM-DD".
+	Annotate bool
+}
+
+var (
+	carriedPattern     = regexp.MustCompile(`(?:^|\s)carried:(\d+)`)
+	carriedFromPattern = regexp.MustCompile(`(?:^|\s)from:(\d{4}-\d{2}-\d{2})`)
+)
+
+// Rollover carries the open tasks of the period before date into the period
+// of date, keeping their nesting. Subtasks of completed tasks are carried
+// one level up. Tasks whose text is already in the new period are skipped,
+// so running it twice is harmless. It returns the carried tasks.
+func (v *Vault) Rollover(date time.Time, opts RolloverOptions) ([]Task, error) {
+	prevDate := v.PreviousDate(date)
+	source := v.getFilename(prevDate)
+	target := v.getFilename(date)
+	if source == target {
+		return nil, fmt.Errorf("%s is in the same period as the previous one", date.Format(taskDateLayout))
+	}
+	if !fileExists(source) {
+		return nil, nil
+	}
+
+	var carried []Task
+	err := v.updateFiles([]time.Time{prevDate, date}, func(contents [][]byte) ([][]byte, error) {
+		sourceLines := strings.Split(string(contents[0]), "\n")
+		existing := map[string]bool{}
+		for i, line := range strings.Split(string(contents[1]), "\n") {
+			if task, ok := ParseTask(line, i+1); ok {
+				existing[carryKey(task.Text)] = true
+			}
+		}
+
+		var tasks []Task
+		for i, line := range sourceLines {
+			if task, ok := ParseTask(line, i+1); ok && strings.TrimSpace(task.Text) != "" {
+				tasks = append(tasks, task)
+			}
+		}
+
+		unit := indentUnit(sourceLines)
+		remove := map[int]bool{}
+		var out []string
+		var carry func(node *TaskNode, depth int)
+		carry = func(node *TaskNode, depth int) {
+			task := node.Task
+			if task.Selected {
+				for _, child := range node.Children {
+					carry(child, depth)
+				}
+				return
+			}
+			if existing[carryKey(task.Text)] {
+				return
+			}
+
+			task.Indent = strings.Repeat(unit, depth)
+			if opts.Annotate {
+				task.setText(annotateCarry(task.Text, prevDate))
+			}
+			task.Line = task.String()
+			task.LineNumber = 0
+			out = append(out, task.Line)
+			carried = append(carried, task)
+			if opts.Move && !hasDoneDescendant(node) {
+				remove[node.Task.LineNumber-1] = true
+			}
+			for _, child := range node.Children {
+				carry(child, depth+1)
+			}
+		}
+		for _, root := range BuildTaskTree(tasks) {
+			carry(root, 0)
+		}
+
+		var kept []string
+		for i, line := range sourceLines {
+			if !remove[i] {
+				kept = append(kept, line)
+			}
+		}
+		return [][]byte{
+			[]byte(strings.Join(kept, "\n")),
+			appendLines(contents[1], out),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return carried, nil
+}
+
+// ensurePeriod runs the automatic rollover, if enabled, before the first
+// write to the file of a new period.
+func (v *Vault) ensurePeriod(date time.Time) error {
+	if v.config.Rollover != "copy" && v.config.Rollover != "move" {
+		return nil
+	}
+	if fileExists(v.getFilename(date)) {
+		return nil
+	}
+	_, err := v.Rollover(date, RolloverOptions{Move: v.config.Rollover == "move", Annotate: v.config.RolloverAnnotate})
+	return err
+}
+
+func hasDoneDescendant(node *TaskNode) bool {
+	for _, child := range node.Children {
+		if child.Task.Selected || hasDoneDescendant(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotateCarry sets the from: marker to from and increments the carried: count,
+// keeping a trailing ^id last.
+func annotateCarry(text string, from time.Time) string {
+	count := 0
+	if m := carriedPattern.FindStringSubmatch(text); m != nil {
+		count, _ = strconv.Atoi(m[1])
+	}
+	text = carriedPattern.ReplaceAllString(text, "")
+	text = strings.TrimSpace(carriedFromPattern.ReplaceAllString(text, ""))
+	note := fmt.Sprintf("carried:%d from:%s", count+1, from.Format(taskDateLayout))
+	if m := idPattern.FindStringIndex(text); m != nil {
+		return strings.TrimSpace(text[:m[0]]+" "+note) + " " + strings.TrimSpace(text[m[0]:])
+	}
+	return strings.TrimSpace(text + " " + note)
+}
+
+// carryKey is the task text without carry or focus annotations, used to
+// recognise a task that was already carried.
+func carryKey(text string) string {
+	text = carriedPattern.ReplaceAllString(text, "")
+	text = carriedFromPattern.ReplaceAllString(text, "")
+	text = pomodoroPattern.ReplaceAllString(text, "")
+	text = focusPattern.ReplaceAllString(text, "")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRolloverCopy(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	prev := time.Date(2024, 8, 29, 0, 0, 0, 0, time.UTC)
+	date := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	prevContent := "2024-08-29 Thursday\n\n- [ ] Open\n    - [x] Done child\n    - [ ] Open child\n- [x] Done parent\n    - [ ] Orphan\n- [ ] Again carried:2 from:2024-08-27\n"
+	source := writeTestFile(t, v, prev, prevContent)
+
+	carried, err := v.Rollover(date, RolloverOptions{Annotate: true})
+	if err != nil {
+		t.Fatalf("Rollover() error = %v", err)
+	}
+	if len(carried) != 4 {
+		t.Fatalf("carried %d tasks, want 4", len(carried))
+	}
+	if carried[3].CarryCount != 3 || !carried[3].CarriedFrom.Equal(time.Date(2024, 8, 29, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("carried[3] count = %d, from = %v", carried[3].CarryCount, carried[3].CarriedFrom)
+	}
+
+	want := "2024-08-30 Friday\n\n" +
+		"- [ ] Open carried:1 from:2024-08-29\n" +
+		"    - [ ] Open child carried:1 from:2024-08-29\n" +
+		"- [ ] Orphan carried:1 from:2024-08-29\n" +
+		"- [ ] Again carried:3 from:2024-08-29\n"
+	target := v.getFilename(date)
+	if got := readTestFile(t, target); got != want {
+		t.Errorf("target content = %q, want %q", got, want)
+	}
+	if got := readTestFile(t, source); got != prevContent {
+		t.Errorf("source content = %q, want it unchanged", got)
+	}
+
+	// A second run finds everything already carried.
+	if carried, _ := v.Rollover(date, RolloverOptions{Annotate: true}); len(carried) != 0 {
+		t.Errorf("second Rollover() carried %d tasks, want 0", len(carried))
+	}
+	if got := readTestFile(t, target); got != want {
+		t.Errorf("target content after second run = %q", got)
+	}
+}
+
+func TestRolloverKeepsID(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	prev := time.Date(2024, 8, 29, 0, 0, 0, 0, time.Local)
+	date := prev.AddDate(0, 0, 1)
+	writeTestFile(t, v, prev, "- [ ] Review #work ^review-1\n")
+
+	carried, err := v.Rollover(date, RolloverOptions{Annotate: true})
+	if err != nil || len(carried) != 1 || carried[0].ID != "review-1" {
+		t.Fatalf("Rollover() = %v, %v; want the task with its ID", carried, err)
+	}
+	if got, want := readTestFile(t, v.getFilename(date)), "2024-08-30 Friday\n\n- [ ] Review #work carried:1 from:2024-08-29 ^review-1\n"; got != want {
+		t.Errorf("target content = %q, want %q", got, want)
+	}
+}
+
+func TestRolloverMove(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	prev := time.Date(2024, 8, 29, 0, 0, 0, 0, time.UTC)
+	date := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	source := writeTestFile(t, v, prev, "- [ ] Move me\n- [x] Done\n- [ ] Keep, has history\n  - [x] Done child\n  - [ ] Open child\n")
+
+	if _, err := v.Rollover(date, RolloverOptions{Move: true}); err != nil {
+		t.Fatalf("Rollover() error = %v", err)
+	}
+	if got, want := readTestFile(t, source), "- [x] Done\n- [ ] Keep, has history\n  - [x] Done child\n"; got != want {
+		t.Errorf("source content = %q, want %q", got, want)
+	}
+	if got, want := readTestFile(t, v.getFilename(date)), "2024-08-30 Friday\n\n- [ ] Move me\n- [ ] Keep, has history\n  - [ ] Open child\n"; got != want {
+		t.Errorf("target content = %q, want %q", got, want)
+	}
+}
+
+func TestAutomaticRollover(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily", Rollover: "copy"})
+	prev := time.Date(2024, 8, 29, 0, 0, 0, 0, time.UTC)
+	date := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	writeTestFile(t, v, prev, "- [ ] Unfinished\n- [x] Finished\n")
+
+	if err := v.AddTask(date, "New"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, v.getFilename(date)), "2024-08-30 Friday\n\n- [ ] Unfinished\n- [ ] New\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+
+	// Existing files are left alone.
+	if err := v.AddTask(date, "Another"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, v.getFilename(date)), "2024-08-30 Friday\n\n- [ ] Unfinished\n- [ ] New\n- [ ] Another\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
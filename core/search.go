@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// slashedPattern finds a /regular expression/ in a search query.
+	slashedPattern = regexp.MustCompile(`(?:^|\s)/(.+)/(?:\s|$)`)
+	// queryTagPattern is a #tag word, written as tags are in tasks.
+	queryTagPattern = regexp.MustCompile(`^#[\p{L}\p{N}_/-]+$`)
+)
+
+// SearchQuery selects tasks across the whole vault.
+type SearchQuery struct {
+	TaskFilter
+	Pattern  *regexp.Regexp // matched against the task text if set
+	From, To time.Time      // only periods overlapping this range, if set
+}
+
+// SearchResult is a task found by Search and the period file it is in.
+type SearchResult struct {
+	Date     time.Time // the first day of the period
+	Filename string
+	Task     Task
+}
+
+// ParseSearchQuery builds a query from words as typed on the command line or
+// in the TUI: #tag requires a tag, is:done and is:open the status, and the
+// remaining words are matched as a case-insensitive substring, or as a
+// regular expression if regex is set. With regex, or when # is not followed
+// by a valid tag, a #word is part of the text. A /regular expression/ in
+// slashes is always one, and may contain spaces and #.
+func ParseSearchQuery(input string, regex bool) (SearchQuery, error) {
+	var q SearchQuery
+	tags := !regex
+	pattern := ""
+	if m := slashedPattern.FindStringSubmatchIndex(input); m != nil {
+		pattern, regex = input[m[2]:m[3]], true
+		input = input[:m[0]] + " " + input[m[1]:]
+	}
+
+	var words []string
+	for _, word := range strings.Fields(input) {
+		switch {
+		case tags && queryTagPattern.MatchString(word):
+			q.Tags = append(q.Tags, word[1:])
+		case word == "is:done" || word == "is:open":
+			q.Status = strings.TrimPrefix(word, "is:")
+		default:
+			words = append(words, word)
+		}
+	}
+
+	text := strings.Join(words, " ")
+	if !regex {
+		q.Text = text
+		return q, nil
+	}
+	if pattern == "" {
+		pattern = text
+	} else if text != "" {
+		q.Text = text
+	}
+	compiled, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return q, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	q.Pattern = compiled
+	return q, nil
+}
+
+// Match reports whether the task matches the query, ignoring its dates.
+func (q SearchQuery) Match(t Task) bool {
+	if !q.TaskFilter.Match(t) {
+		return false
+	}
+	return q.Pattern == nil || q.Pattern.MatchString(t.Text)
+}
+
+// Search returns the tasks matching q in every period file of the vault, in
+// any layout, ordered by date. It reads the vault through the Index.
+func (v *Vault) Search(q SearchQuery) ([]SearchResult, error) {
+	files, err := v.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, file := range files {
+		if !v.overlaps(file, q.From, q.To) {
+			continue
+		}
+		for _, task := range file.Tasks {
+			if q.Match(task) {
+				results = append(results, SearchResult{Date: file.Date, Filename: file.Filename, Task: task})
+			}
+		}
+	}
+	return results, nil
+}
+
+// overlaps reports whether the period of file, in its own layout, has a day
+// from from to to. A zero from or to leaves that end open.
+func (v *Vault) overlaps(file IndexedFile, from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	start, end := v.withMode(file.Mode).periodBounds(file.Date)
+	return (from.IsZero() || !end.Before(truncateDay(from))) && (to.IsZero() || !start.After(truncateDay(to)))
+}
+
+// withMode returns a vault like v that uses the layout of mode.
+func (v *Vault) withMode(mode string) *Vault {
+	config := v.config
+	config.IntervalMode = mode
+	return NewVault(config)
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery("#work review  is:open PR", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Text != "review PR" || q.Status != "open" || len(q.Tags) != 1 || q.Tags[0] != "work" || q.Pattern != nil {
+		t.Errorf("ParseSearchQuery() = %+v", q)
+	}
+
+	q, err = ParseSearchQuery("/^review PR #\\d+$/", false)
+	if err != nil || q.Pattern == nil || q.Text != "" {
+		t.Fatalf("ParseSearchQuery() with slashes = %+v, %v", q, err)
+	}
+	if !q.Pattern.MatchString("Review PR #42") || q.Pattern.MatchString("review PR") {
+		t.Errorf("pattern %s matches wrongly", q.Pattern)
+	}
+
+	q, err = ParseSearchQuery("PR #[0-9]+ is:open", true)
+	if err != nil || q.Pattern == nil || len(q.Tags) != 0 || q.Status != "open" {
+		t.Fatalf("ParseSearchQuery() with regex = %+v, %v", q, err)
+	}
+	if !q.Pattern.MatchString("Review PR #42") || q.Pattern.MatchString("Review PR") {
+		t.Errorf("pattern %s matches wrongly", q.Pattern)
+	}
+
+	// # not followed by a tag is searched for.
+	q, _ = ParseSearchQuery("issue #? #todo", false)
+	if q.Text != "issue #?" || len(q.Tags) != 1 || q.Tags[0] != "todo" {
+		t.Errorf("ParseSearchQuery() with a stray # = %+v", q)
+	}
+
+	if _, err := ParseSearchQuery("(", true); err == nil {
+		t.Error("ParseSearchQuery() with an invalid regex should fail")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily"})
+	weekly := NewVault(Config{VaultLoc: dir, IntervalMode: "weekly"})
+	day := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+
+	writeTestFile(t, v, day.AddDate(0, 0, -1), "- [x] Dentist #health\n- [ ] Review PR #work\n")
+	writeTestFile(t, v, day, "- [ ] Dentist again #health\n")
+	writeTestFile(t, weekly, day.AddDate(0, -1, 0), "Week 31\n\n- [x] Dentist\n")
+
+	q, _ := ParseSearchQuery("dentist", false)
+	results, err := v.Search(q)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Date.Format(taskDateLayout)+" "+r.Task.Text)
+	}
+	want := []string{"2024-07-29 Dentist", "2024-08-29 Dentist #health", "2024-08-30 Dentist again #health"}
+	if len(got) != len(want) {
+		t.Fatalf("Search() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Search()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	q, _ = ParseSearchQuery("#health is:done", false)
+	if results, _ := v.Search(q); len(results) != 1 || results[0].Task.LineNumber != 1 {
+		t.Errorf("Search(#health is:done) = %v", results)
+	}
+
+	q, _ = ParseSearchQuery("dentist", false)
+	q.From = day
+	if results, _ := v.Search(q); len(results) != 1 {
+		t.Errorf("Search() from %s = %v, want 1 result", day.Format(taskDateLayout), results)
+	}
+}
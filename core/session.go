@@ -1,21 +1,59 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"time"
 )
 
 const defaultSessionDuration time.Duration = time.Duration(25 * time.Minute)
 
-type session struct {
+// Session outcomes.
+const (
+	SessionCompleted = "completed"
+	SessionAbandoned = "abandoned"
+)
+
+// Session is one run of the pomodoro timer, as kept in the session log.
+type Session struct {
 	Start    time.Time `json:"start"`
-	Duration int       `json:"duration"`
+	End      time.Time `json:"end"`
+	Duration int       `json:"duration"` // planned, in minutes
+	Actual   int       `json:"actual"`   // time spent focused, in seconds
+	Pauses   []Pause   `json:"pauses,omitempty"`
+	Status   string    `json:"status"` // SessionCompleted or SessionAbandoned
 	Category string    `json:"category"`
+	Task     string    `json:"task,omitempty"` // the task worked on, without annotations
+}
+
+// Pause is a stretch of a session during which the timer was paused.
+type Pause struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Planned returns how long the session was meant to last.
+func (s Session) Planned() time.Duration {
+	return time.Duration(s.Duration) * time.Minute
+}
+
+// Focused returns how long the timer actually ran, pauses excluded.
+func (s Session) Focused() time.Duration {
+	return time.Duration(s.Actual) * time.Second
+}
+
+// Paused returns the total time the session spent paused.
+func (s Session) Paused() time.Duration {
+	var total time.Duration
+	for _, pause := range s.Pauses {
+		total += pause.End.Sub(pause.Start)
+	}
+	return total
 }
 
 func printElapsed(d time.Duration) {
@@ -23,7 +61,7 @@ func printElapsed(d time.Duration) {
 	fmt.Println(d.Truncate(1 * time.Second))
 }
 
-func runSession(duration time.Duration, category string, timerEnabled bool) session {
+func runSession(duration time.Duration, category string, timerEnabled bool) Session {
 	startTime := time.Now()
 	elapsed := time.Since(startTime)
 
@@ -39,65 +77,91 @@ func runSession(duration time.Duration, category string, timerEnabled bool) sess
 		time.Sleep(100 * time.Millisecond)
 		elapsed = time.Since(startTime)
 	}
-	return session{Start: startTime, Duration: int(duration.Minutes()), Category: category}
+	return Session{
+		Start:    startTime,
+		End:      time.Now(),
+		Duration: int(duration.Minutes()),
+		Actual:   int(elapsed.Seconds()),
+		Status:   SessionCompleted,
+		Category: category,
+	}
 }
 
-func loadSessions(filename string) ([]session, error) {
-
-	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0755)
+// loadSessions reads a session log. A missing log holds no sessions.
+func loadSessions(filename string) ([]Session, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	var sessions []session
+	var sessions []Session
 	decoder := json.NewDecoder(file)
 	err = decoder.Decode(&sessions)
 	if err != nil && err != io.EOF {
-		return nil, err
+		return nil, fmt.Errorf("error reading %s: %w", filename, err)
 	}
 
 	return sessions, nil
 }
 
-func saveSessions(filename string, sessions []session) error {
-	file, err := os.Create(filename)
-	if err != nil {
+// saveSessions replaces the session log atomically.
+func saveSessions(filename string, sessions []Session) error {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(sessions); err != nil {
 		return err
 	}
-	defer file.Close()
+	return writeFileAtomic(filename, out.Bytes())
+}
 
-	encoder := json.NewEncoder(file)
-	err = encoder.Encode(sessions)
-	if err != nil {
-		return err
-	}
+// sessionFile is the vault's session log.
+func (v *Vault) sessionFile() string {
+	return filepath.Join(v.config.VaultLoc, "sessions.json")
+}
+
+// Sessions returns the vault's recorded pomodoro sessions, oldest first.
+func (v *Vault) Sessions() ([]Session, error) {
+	return loadSessions(v.sessionFile())
+}
 
-	return nil
+// RecordSession appends s to the session log. The log is locked while it is
+// rewritten, so timers finishing at the same time do not lose each other's
+// sessions.
+func (v *Vault) RecordSession(s Session) error {
+	filename := v.sessionFile()
+	return v.withLock(filename, func() error {
+		sessions, err := loadSessions(filename)
+		if err != nil {
+			return err
+		}
+		return saveSessions(filename, append(sessions, s))
+	})
 }
 
-func SendNotification(msg string, silent bool) {
+// SendNotification shows msg as a desktop notification, printing it first
+// if silent is set. The timer carries on between phases, so a missing
+// notify-send is returned rather than fatal.
+func SendNotification(msg string, silent bool) error {
 	if silent {
 		fmt.Println(msg)
 		fmt.Println()
 	}
-	err := exec.Command("notify-send", msg).Run()
-	if err != nil {
-		log.Fatal(err)
-	}
+	return exec.Command("notify-send", msg).Run()
 }
 
-func PauseMusic() {
-  execPlayerctl("pause")
+func PauseMusic() error {
+	return execPlayerctl("pause")
 }
 
-func PlayMusic() {
-  execPlayerctl("play")
+func PlayMusic() error {
+	return execPlayerctl("play")
 }
 
-func execPlayerctl(subcmd string) {
-  err := exec.Command("playerctl", subcmd).Run()
-  if err != nil {
-    log.Fatal(err)
-  }
+func execPlayerctl(subcmd string) error {
+	return exec.Command("playerctl", subcmd).Run()
 }
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordSession(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	if sessions, err := v.Sessions(); err != nil || len(sessions) != 0 {
+		t.Fatalf("Sessions() without a log = %v, %v", sessions, err)
+	}
+
+	start := time.Date(2024, 8, 30, 9, 0, 0, 0, time.Local)
+	s := Session{
+		Start:    start,
+		End:      start.Add(27 * time.Minute),
+		Duration: 25,
+		Actual:   25 * 60,
+		Pauses:   []Pause{{Start: start.Add(10 * time.Minute), End: start.Add(12 * time.Minute)}},
+		Status:   SessionCompleted,
+		Category: "writing",
+	}
+	if err := v.RecordSession(s); err != nil {
+		t.Fatalf("RecordSession() error = %v", err)
+	}
+	sessions, err := v.Sessions()
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("Sessions() = %v, %v", sessions, err)
+	}
+	got := sessions[0]
+	if !got.Start.Equal(start) || got.Status != SessionCompleted || got.Category != "writing" {
+		t.Errorf("Sessions()[0] = %+v", got)
+	}
+	if got.Planned() != 25*time.Minute || got.Focused() != 25*time.Minute || got.Paused() != 2*time.Minute {
+		t.Errorf("planned %v, focused %v, paused %v", got.Planned(), got.Focused(), got.Paused())
+	}
+}
+
+func TestRecordSessionConcurrently(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := v.RecordSession(Session{Duration: i, Status: SessionAbandoned}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if sessions, err := v.Sessions(); err != nil || len(sessions) != 10 {
+		t.Errorf("Sessions() = %d sessions, %v, want 10", len(sessions), err)
+	}
+}
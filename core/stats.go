@@ -0,0 +1,164 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SessionStats sums up a set of pomodoro sessions.
+type SessionStats struct {
+	Sessions  int
+	Completed int
+	Focus     time.Duration // time spent focused, pauses excluded
+	Pauses    int
+	Paused    time.Duration
+}
+
+func (s *SessionStats) add(session Session) {
+	s.Sessions++
+	if session.Status == SessionCompleted {
+		s.Completed++
+	}
+	s.Focus += session.Focused()
+	s.Pauses += len(session.Pauses)
+	s.Paused += session.Paused()
+}
+
+// CompletionRate is the share of sessions that ran to the end, from 0 to 1.
+func (s SessionStats) CompletionRate() float64 {
+	if s.Sessions == 0 {
+		return 0
+	}
+	return float64(s.Completed) / float64(s.Sessions)
+}
+
+// AverageFocus is the mean focus time of a session.
+func (s SessionStats) AverageFocus() time.Duration {
+	if s.Sessions == 0 {
+		return 0
+	}
+	return s.Focus / time.Duration(s.Sessions)
+}
+
+// StatsBucket is the sessions started in the period beginning on Start.
+type StatsBucket struct {
+	Start time.Time
+	SessionStats
+}
+
+// StatsGroup is the sessions of one category or task.
+type StatsGroup struct {
+	Name string
+	SessionStats
+}
+
+// Stats is a report of the pomodoro sessions started in a date range.
+type Stats struct {
+	From, To   time.Time
+	Total      SessionStats
+	Buckets    []StatsBucket // one per day, week or month of the range, empty ones included
+	Days       []StatsBucket // one per day of the range, for a heatmap
+	Categories []StatsGroup  // most focus first
+	Tasks      []StatsGroup  // most focus first, sessions without a task left out
+}
+
+// Stats reports on the vault's sessions started from from to to, both days
+// included, with Buckets of the given size: ZoomDay, ZoomWeek or ZoomMonth.
+func (v *Vault) Stats(from, to time.Time, by string) (Stats, error) {
+	sessions, err := v.Sessions()
+	if err != nil {
+		return Stats{}, err
+	}
+	return ComputeStats(sessions, from, to, by)
+}
+
+// ComputeStats is Stats for the given sessions.
+func ComputeStats(sessions []Session, from, to time.Time, by string) (Stats, error) {
+	if err := checkRange(from, to); err != nil {
+		return Stats{}, err
+	}
+	from, to = truncateDay(from), truncateDay(to)
+	if by != ZoomDay && by != ZoomWeek && by != ZoomMonth {
+		return Stats{}, fmt.Errorf("unknown period %q: expected day, week or month", by)
+	}
+
+	stats := Stats{From: from, To: to}
+	for start := bucketStart(from, by); !start.After(to); start = nextBucket(start, by) {
+		stats.Buckets = append(stats.Buckets, StatsBucket{Start: start})
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		stats.Days = append(stats.Days, StatsBucket{Start: day})
+	}
+
+	categories := map[string]*SessionStats{}
+	tasks := map[string]*SessionStats{}
+	for _, session := range sessions {
+		day := truncateDay(session.Start.Local())
+		if day.Before(from) || day.After(to) {
+			continue
+		}
+		stats.Total.add(session)
+		stats.Days[daysBetween(from, day)].add(session)
+		for i := len(stats.Buckets) - 1; i >= 0; i-- {
+			if !stats.Buckets[i].Start.After(day) {
+				stats.Buckets[i].add(session)
+				break
+			}
+		}
+
+		category := session.Category
+		if category == "" {
+			category = "(none)"
+		}
+		addToGroup(categories, category, session)
+		if session.Task != "" {
+			addToGroup(tasks, session.Task, session)
+		}
+	}
+	stats.Categories = sortedGroups(categories)
+	stats.Tasks = sortedGroups(tasks)
+	return stats, nil
+}
+
+func addToGroup(groups map[string]*SessionStats, name string, session Session) {
+	if groups[name] == nil {
+		groups[name] = &SessionStats{}
+	}
+	groups[name].add(session)
+}
+
+func sortedGroups(groups map[string]*SessionStats) []StatsGroup {
+	var out []StatsGroup
+	for name, stats := range groups {
+		out = append(out, StatsGroup{Name: name, SessionStats: *stats})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Focus != out[j].Focus {
+			return out[i].Focus > out[j].Focus
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// bucketStart returns the first day of the day, week or month holding date.
+func bucketStart(date time.Time, by string) time.Time {
+	switch by {
+	case ZoomWeek:
+		return date.AddDate(0, 0, -daysSinceMonday(date))
+	case ZoomMonth:
+		return date.AddDate(0, 0, 1-date.Day())
+	}
+	return date
+}
+
+func nextBucket(start time.Time, by string) time.Time {
+	switch by {
+	case ZoomWeek:
+		return start.AddDate(0, 0, 7)
+	case ZoomMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
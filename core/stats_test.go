@@ -0,0 +1,55 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	day := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local) // a Friday
+	at := func(days, hour int) time.Time { return day.AddDate(0, 0, days).Add(time.Duration(hour) * time.Hour) }
+	pause := []Pause{{Start: at(0, 9), End: at(0, 9).Add(3 * time.Minute)}}
+	sessions := []Session{
+		{Start: at(-10, 9), Actual: 25 * 60, Status: SessionCompleted}, // before the range
+		{Start: at(-4, 9), Actual: 25 * 60, Status: SessionCompleted, Category: "writing", Task: "Report"},
+		{Start: at(0, 9), Actual: 25 * 60, Status: SessionCompleted, Category: "writing", Task: "Report", Pauses: pause},
+		{Start: at(0, 14), Actual: 10 * 60, Status: SessionAbandoned},
+	}
+
+	stats, err := ComputeStats(sessions, day.AddDate(0, 0, -6), day, ZoomWeek)
+	if err != nil {
+		t.Fatalf("ComputeStats() error = %v", err)
+	}
+	total := stats.Total
+	if total.Sessions != 3 || total.Completed != 2 || total.Focus != 60*time.Minute || total.Pauses != 1 || total.Paused != 3*time.Minute {
+		t.Errorf("Total = %+v", total)
+	}
+	if total.AverageFocus() != 20*time.Minute || total.CompletionRate() < 0.66 || total.CompletionRate() > 0.67 {
+		t.Errorf("average %v, completion %v", total.AverageFocus(), total.CompletionRate())
+	}
+
+	// 2024-08-24 to 2024-08-30 spans the ISO weeks starting on the 19th and the 26th.
+	if len(stats.Buckets) != 2 || !stats.Buckets[1].Start.Equal(day.AddDate(0, 0, -4)) {
+		t.Fatalf("Buckets = %+v", stats.Buckets)
+	}
+	if stats.Buckets[0].Sessions != 0 || stats.Buckets[1].Focus != 60*time.Minute {
+		t.Errorf("Buckets = %+v", stats.Buckets)
+	}
+	if len(stats.Days) != 7 || stats.Days[6].Focus != 35*time.Minute || stats.Days[2].Sessions != 1 {
+		t.Errorf("Days = %+v", stats.Days)
+	}
+
+	if len(stats.Categories) != 2 || stats.Categories[0].Name != "writing" || stats.Categories[1].Name != "(none)" {
+		t.Errorf("Categories = %+v", stats.Categories)
+	}
+	if len(stats.Tasks) != 1 || stats.Tasks[0].Name != "Report" || stats.Tasks[0].Focus != 50*time.Minute {
+		t.Errorf("Tasks = %+v", stats.Tasks)
+	}
+
+	if _, err := ComputeStats(sessions, day, day.AddDate(0, 0, -1), ZoomDay); err == nil {
+		t.Error("ComputeStats() with an inverted range should fail")
+	}
+	if _, err := ComputeStats(sessions, day, day, "year"); err == nil {
+		t.Error("ComputeStats() with an unknown period should fail")
+	}
+}
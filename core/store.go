@@ -0,0 +1,201 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned when another process holds a file's lock for longer
+// than lockTimeout, for example while the file is open in the editor.
+var ErrLocked = errors.New("file is locked by another td process")
+
+const lockTimeout = 10 * time.Second
+
+// Every period file keeps its previous content in a backup file, and has a
+// lock file under lockDir. Files with these suffixes are not period files.
+const (
+	lockSuffix   = ".lock"
+	backupSuffix = ".bak"
+)
+
+// lockDir is the hidden directory of the vault that holds the lock files of
+// its files, at the same relative paths.
+const lockDir = ".locks"
+
+// lockFile is the lock file of filename.
+func (v *Vault) lockFile(filename string) string {
+	rel, err := filepath.Rel(v.config.VaultLoc, filename)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filename + lockSuffix
+	}
+	return filepath.Join(v.config.VaultLoc, lockDir, rel+lockSuffix)
+}
+
+// withLock runs fn while holding an exclusive advisory lock on filename.
+// The lock lives in a separate file so it survives the file being replaced.
+func (v *Vault) withLock(filename string, fn func() error) error {
+	lockName := v.lockFile(filename)
+	for _, dir := range []string{filepath.Dir(filename), filepath.Dir(lockName)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	lock, err := os.OpenFile(lockName, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer lock.Close()
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLock(lock)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", filename, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", ErrLocked, filename)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	defer unlock(lock)
+
+	return fn()
+}
+
+// writeFileAtomic replaces filename with data by writing a temporary file in
+// the same directory and renaming it over the original, whose permissions
+// are kept.
+func writeFileAtomic(filename string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// replaceFile writes data to filename atomically, first saving old (the
+// content being replaced) to the backup file. A nil old skips the backup.
+func replaceFile(filename string, old, data []byte) error {
+	if old != nil {
+		if err := writeFileAtomic(filename+backupSuffix, old); err != nil {
+			return fmt.Errorf("error writing backup: %w", err)
+		}
+	}
+	if err := writeFileAtomic(filename, data); err != nil {
+		return fmt.Errorf("error writing to file: %w", err)
+	}
+	return nil
+}
+
+// withLocks is withLock for several files. Locks are taken in name order
+// so that two processes locking the same files cannot deadlock.
+func (v *Vault) withLocks(filenames []string, fn func() error) error {
+	sorted := append([]string(nil), filenames...)
+	sort.Strings(sorted)
+	var lockNext func(i int) error
+	lockNext = func(i int) error {
+		if i == len(sorted) {
+			return fn()
+		}
+		if i > 0 && sorted[i] == sorted[i-1] {
+			return lockNext(i + 1)
+		}
+		return v.withLock(sorted[i], func() error { return lockNext(i + 1) })
+	}
+	return lockNext(0)
+}
+
+// restoreFiles puts back the files written by a failed updateFiles: olds
+// are their previous contents, nil for files that did not exist.
+func restoreFiles(filenames []string, olds [][]byte) {
+	for i, filename := range filenames {
+		if olds[i] == nil {
+			os.Remove(filename)
+		} else {
+			writeFileAtomic(filename, olds[i])
+		}
+	}
+}
+
+// updateFile applies fn to the content of the period file for date and
+// stores the result, all under the file's lock. A missing file starts out as
+// the rendered template.
+func (v *Vault) updateFile(date time.Time, fn func(content []byte) ([]byte, error)) error {
+	return v.updateFiles([]time.Time{date}, func(contents [][]byte) ([][]byte, error) {
+		updated, err := fn(contents[0])
+		return [][]byte{updated}, err
+	})
+}
+
+// updateFiles is updateFile for changes spanning several files, such as
+// moving a task. fn receives and returns contents in the order of dates.
+// Nothing is written unless fn succeeds, and if writing one of the files
+// fails the ones already written are put back.
+func (v *Vault) updateFiles(dates []time.Time, fn func(contents [][]byte) ([][]byte, error)) error {
+	filenames := make([]string, len(dates))
+	for i, date := range dates {
+		filenames[i] = v.getFilename(date)
+	}
+	return v.withLocks(filenames, func() error {
+		contents := make([][]byte, len(filenames))
+		olds := make([][]byte, len(filenames))
+		for i, filename := range filenames {
+			content, err := os.ReadFile(filename)
+			if err == nil {
+				olds[i] = content
+			} else if os.IsNotExist(err) {
+				content, err = v.newPeriodContent(dates[i])
+				if err != nil {
+					return err
+				}
+			} else {
+				return fmt.Errorf("error reading file: %w", err)
+			}
+			contents[i] = content
+		}
+
+		updated, err := fn(contents)
+		if err != nil {
+			return err
+		}
+		for i, filename := range filenames {
+			if olds[i] != nil && bytes.Equal(olds[i], updated[i]) {
+				continue
+			}
+			if err := replaceFile(filename, olds[i], updated[i]); err != nil {
+				restoreFiles(filenames[:i], olds[:i])
+				return err
+			}
+		}
+		return nil
+	})
+}
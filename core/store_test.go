@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentAddTask(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := v.AddTask(testDate, fmt.Sprintf("Task %d", i)); err != nil {
+				t.Errorf("AddTask() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	content := readTestFile(t, v.getFilename(testDate))
+	if got := strings.Count(content, "- [ ] Task "); got != n {
+		t.Errorf("file has %d tasks, want %d:\n%s", got, n, content)
+	}
+}
+
+func TestUpdateKeepsBackup(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Task 1\n")
+
+	task, _ := ParseTask("- [ ] Task 1", 1)
+	if err := v.UpdateTaskStatus(task.Address(testDate), true); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+
+	if got := readTestFile(t, filename+backupSuffix); got != "- [ ] Task 1\n" {
+		t.Errorf("backup content = %q, want the previous version", got)
+	}
+	if got := readTestFile(t, filename); got != "- [x] Task 1\n" {
+		t.Errorf("File content = %q", got)
+	}
+}
+
+func TestUpdateKeepsMode(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Task 1\n")
+	if err := os.Chmod(filename, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.AddTask(testDate, "Task 2"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestMoveTaskRestoresSourceOnFailure(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	from := time.Date(2024, 8, 29, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+	source := writeTestFile(t, v, from, "- [ ] Move me\n")
+	target := writeTestFile(t, v, to, "- [ ] Other\n")
+	// A directory in the way of the target's backup makes its write fail.
+	if err := os.MkdirAll(filepath.Join(target+backupSuffix, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	task, _ := ParseTask("- [ ] Move me", 1)
+	if err := v.MoveTask(task.Address(from), to); err == nil {
+		t.Fatal("MoveTask() should fail")
+	}
+	if got := readTestFile(t, source); got != "- [ ] Move me\n" {
+		t.Errorf("source content = %q, want it restored", got)
+	}
+	if got := readTestFile(t, target); got != "- [ ] Other\n" {
+		t.Errorf("target content = %q, want it unchanged", got)
+	}
+}
+
+func TestAddTaskTerminatesLastLine(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Task 1")
+
+	if err := v.AddTask(testDate, "Task 2"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] Task 1\n- [ ] Task 2\n"; got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
+
+func TestEditFileDoesNotHoldTheLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake editor is a shell script")
+	}
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Task 1\n")
+
+	// The editor appends a task, and td adds one while it runs: the editor
+	// signals that it started and waits for the go ahead.
+	dir := t.TempDir()
+	started, proceed := filepath.Join(dir, "started"), filepath.Join(dir, "proceed")
+	editor := filepath.Join(dir, "editor")
+	script := "#!/bin/sh\ntouch " + started + "\nwhile [ ! -f " + proceed + " ]; do sleep 0.01; done\necho '- [ ] Edited' >> \"$2\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	done := make(chan error)
+	go func() { done <- v.editFile(filename, 1) }()
+	for !fileExists(started) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := v.AddTask(testDate, "Added meanwhile"); err != nil {
+		t.Fatalf("AddTask() while editing error = %v", err)
+	}
+	if err := os.WriteFile(proceed, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := <-done
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("editFile() error = %v, want ErrConflict", err)
+	}
+	if got, want := readTestFile(t, filename), "- [ ] Task 1\n- [ ] Edited\n"; got != want {
+		t.Errorf("file content = %q, want %q", got, want)
+	}
+	if got := readTestFile(t, filename+backupSuffix); !strings.Contains(got, "Added meanwhile") {
+		t.Errorf("backup content = %q, want the concurrent version", got)
+	}
+	if fileExists(filename + lockSuffix) {
+		t.Errorf("lock file left next to %s", filename)
+	}
+}
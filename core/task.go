@@ -0,0 +1,148 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Task is a checkbox line parsed from a period file.
+//
+// Line, Indent, Selected and Text describe the markdown line itself and are
+// what String renders; the remaining fields are metadata derived from Text.
+type Task struct {
+	Line       string // the line as read from the file
+	Selected   bool
+	Indent     string // leading whitespace, kept verbatim
+	Depth      int    // nesting in the task tree, see setDepths; 0 from ParseTask
+	Text       string // everything after the checkbox
+	Tags       []string
+	Contexts   []string
+	Priority   int // number of ! in a priority marker such as !!, 0 if none
+	Due        time.Time
+	Scheduled  time.Time
+	ID         string
+	LineNumber int // 1-based line in the source file, 0 if not read from a file
+
+	CarriedFrom time.Time // the period a carried task came from, see Rollover
+	CarryCount  int       // how many times the task was carried over
+
+	Pomodoros int           // completed pomodoros counted against it, see RecordFocus
+	Focus     time.Duration // time spent on it in pomodoro sessions
+
+	noSpace bool // the checkbox was not followed by a space
+}
+
+const taskDateLayout = "2006-01-02"
+
+var (
+	checkboxPattern  = regexp.MustCompile(`^([\t ]*)- \[( |x)\]( ?)(.*)$`)
+	tagPattern       = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+	contextPattern   = regexp.MustCompile(`(?:^|\s)@([\p{L}\p{N}_/-]+)`)
+	priorityPattern  = regexp.MustCompile(`(?:^|\s)(!{1,3})(?:\s|$)`)
+	duePattern       = regexp.MustCompile(`(?:^|\s)due:(\d{4}-\d{2}-\d{2})`)
+	scheduledPattern = regexp.MustCompile(`(?:^|\s)scheduled:(\d{4}-\d{2}-\d{2})`)
+	idPattern        = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)$`)
+)
+
+// ParseTask parses a markdown checkbox line such as "  - [x] Write docs #work due:2024-09-01".
+// The second return value is false if the line is not a task.
+func ParseTask(line string, lineNumber int) (Task, bool) {
+	matches := checkboxPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return Task{}, false
+	}
+
+	task := Task{
+		Line:       line,
+		Selected:   matches[2] == "x",
+		Indent:     matches[1],
+		LineNumber: lineNumber,
+		noSpace:    matches[3] == "",
+	}
+	task.setText(matches[4])
+	return task, true
+}
+
+// NewTask returns an unchecked top-level task with the given text.
+func NewTask(text string) Task {
+	task := Task{}
+	task.setText(text)
+	task.Line = task.String()
+	return task
+}
+
+// setText replaces the task text and re-derives its metadata.
+func (t *Task) setText(text string) {
+	t.Text = text
+	t.Tags = submatches(tagPattern, text)
+	t.Contexts = submatches(contextPattern, text)
+	t.Priority = 0
+	if m := priorityPattern.FindStringSubmatch(text); m != nil {
+		t.Priority = len(m[1])
+	}
+	t.Due = parseTaskDate(duePattern, text)
+	t.Scheduled = parseTaskDate(scheduledPattern, text)
+	t.ID = ""
+	if m := idPattern.FindStringSubmatch(text); m != nil {
+		t.ID = m[1]
+	}
+	t.CarriedFrom = parseTaskDate(carriedFromPattern, text)
+	t.CarryCount = 0
+	if m := carriedPattern.FindStringSubmatch(text); m != nil {
+		t.CarryCount, _ = strconv.Atoi(m[1])
+	}
+	t.Pomodoros, t.Focus = 0, 0
+	if m := pomodoroPattern.FindStringSubmatch(text); m != nil {
+		t.Pomodoros, _ = strconv.Atoi(m[1])
+	}
+	if m := focusPattern.FindStringSubmatch(text); m != nil {
+		minutes, _ := strconv.Atoi(m[1])
+		t.Focus = time.Duration(minutes) * time.Minute
+	}
+}
+
+// String renders the task back to its markdown line. For a parsed task that
+// has not been modified it returns exactly the original line.
+func (t Task) String() string {
+	mark := " "
+	if t.Selected {
+		mark = "x"
+	}
+	sep := " "
+	if t.noSpace {
+		sep = ""
+	}
+	return t.Indent + "- [" + mark + "]" + sep + t.Text
+}
+
+func (t Task) HasTag(tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	for _, tt := range t.Tags {
+		if strings.EqualFold(tt, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func submatches(pattern *regexp.Regexp, text string) []string {
+	var out []string
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		out = append(out, m[1])
+	}
+	return out
+}
+
+func parseTaskDate(pattern *regexp.Regexp, text string) time.Time {
+	m := pattern.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}
+	}
+	date, err := time.ParseInLocation(taskDateLayout, m[1], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
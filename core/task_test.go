@@ -0,0 +1,92 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTask(t *testing.T) {
+	line := "\t  - [x] !! Review PR #work #code @office due:2024-09-02 scheduled:2024-08-30 ^pr42"
+	task, ok := ParseTask(line, 7)
+	if !ok {
+		t.Fatalf("ParseTask(%q) ok = false", line)
+	}
+
+	if !task.Selected {
+		t.Errorf("Selected = false, want true")
+	}
+	if task.Indent != "\t  " || task.Depth != 0 {
+		t.Errorf("Indent = %q, Depth = %d, want %q, 0 until nested in a tree", task.Indent, task.Depth, "\t  ")
+	}
+	if task.Text != "!! Review PR #work #code @office due:2024-09-02 scheduled:2024-08-30 ^pr42" {
+		t.Errorf("Text = %q", task.Text)
+	}
+	if !reflect.DeepEqual(task.Tags, []string{"work", "code"}) {
+		t.Errorf("Tags = %v", task.Tags)
+	}
+	if !reflect.DeepEqual(task.Contexts, []string{"office"}) {
+		t.Errorf("Contexts = %v", task.Contexts)
+	}
+	if task.Priority != 2 {
+		t.Errorf("Priority = %d, want 2", task.Priority)
+	}
+	if want := time.Date(2024, 9, 2, 0, 0, 0, 0, time.Local); !task.Due.Equal(want) {
+		t.Errorf("Due = %v, want %v", task.Due, want)
+	}
+	if want := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local); !task.Scheduled.Equal(want) {
+		t.Errorf("Scheduled = %v, want %v", task.Scheduled, want)
+	}
+	if task.ID != "pr42" {
+		t.Errorf("ID = %q, want pr42", task.ID)
+	}
+	if task.LineNumber != 7 {
+		t.Errorf("LineNumber = %d, want 7", task.LineNumber)
+	}
+}
+
+func TestParseTaskRejectsNonTasks(t *testing.T) {
+	for _, line := range []string{"", "Week 35", "- plain bullet", "-[ ] missing space", "# heading"} {
+		if _, ok := ParseTask(line, 1); ok {
+			t.Errorf("ParseTask(%q) ok = true, want false", line)
+		}
+	}
+}
+
+func TestTaskRoundTrip(t *testing.T) {
+	lines := []string{
+		"- [ ] Task 1",
+		"- [x] Task 2",
+		"    - [ ] indented with spaces",
+		"\t- [x] indented with a tab #tag",
+		"- [ ]no space after the box",
+		"- [ ]  two spaces",
+		"- [ ] trailing space ",
+	}
+	for _, line := range lines {
+		task, ok := ParseTask(line, 1)
+		if !ok {
+			t.Errorf("ParseTask(%q) ok = false", line)
+			continue
+		}
+		if got := task.String(); got != line {
+			t.Errorf("String() = %q, want %q", got, line)
+		}
+	}
+
+	task, _ := ParseTask("  - [ ] toggle me", 1)
+	task.Selected = true
+	if got, want := task.String(), "  - [x] toggle me"; got != want {
+		t.Errorf("String() after toggle = %q, want %q", got, want)
+	}
+}
+
+func TestNewTask(t *testing.T) {
+	task := NewTask("Buy milk #home")
+	if task.Line != "- [ ] Buy milk #home" {
+		t.Errorf("Line = %q", task.Line)
+	}
+	if !task.HasTag("#home") {
+		t.Errorf("HasTag(#home) = false")
+	}
+}
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultTemplate is used when the vault has no template of its own.
+const defaultTemplate = "{{.Header}}"
+
+// templateExt marks a single template file as a text/template. Without it
+// the file is copied into new periods as it is, so that existing templates
+// with a literal {{ keep working.
+const templateExt = ".tmpl"
+
+// TemplateData is what a period template is rendered with. The dates are the
+// first and last day of the period the new file holds.
+type TemplateData struct {
+	Date    time.Time
+	End     time.Time
+	Weekday string // e.g. "Monday"
+	Day     int    // day of the month
+	Month   string // e.g. "August"
+	Year    int
+	ISOYear int
+	ISOWeek int
+	Mode    string // the interval mode: daily, weekly or monthly
+	Header  string // the built-in header line, see GetHeader
+}
+
+// periodBounds returns the first and last day stored in the same file as date.
+func (v *Vault) periodBounds(date time.Time) (time.Time, time.Time) {
+	filename := v.getFilename(date)
+	start, end := truncateDay(date), truncateDay(date)
+	for v.getFilename(start.AddDate(0, 0, -1)) == filename {
+		start = start.AddDate(0, 0, -1)
+	}
+	for v.getFilename(end.AddDate(0, 0, 1)) == filename {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+func (v *Vault) templateData(date time.Time) TemplateData {
+	start, end := v.periodBounds(date)
+	isoYear, isoWeek := start.ISOWeek()
+	return TemplateData{
+		Date:    start,
+		End:     end,
+		Weekday: start.Weekday().String(),
+		Day:     start.Day(),
+		Month:   start.Month().String(),
+		Year:    start.Year(),
+		ISOYear: isoYear,
+		ISOWeek: isoWeek,
+		Mode:    v.config.IntervalMode,
+		Header:  v.GetHeader(start),
+	}
+}
+
+// templateNames are the files looked up in a template directory for the
+// period of date, most specific first: the weekday in daily mode, then the
+// interval mode, then default.md.
+func (v *Vault) templateNames(date time.Time) []string {
+	var names []string
+	if v.config.IntervalMode == "daily" {
+		names = append(names, strings.ToLower(date.Weekday().String())+".md")
+	}
+	return append(names, v.config.IntervalMode+".md", "default.md")
+}
+
+// loadTemplate parses the template for the period of date. template_path is
+// either a single template file ending in templateExt or a directory of
+// named templates, which are all parsed together so that one can use another
+// with {{template "name.md" .}}. Other single files are left to
+// renderTemplate.
+func (v *Vault) loadTemplate(date time.Time) (*template.Template, error) {
+	path := v.templateFile()
+	tmpl := template.New("").Option("missingkey=error")
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		files, err := filepath.Glob(filepath.Join(path, "*.md"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			tmpl.Funcs(v.templateFuncs(tmpl, path, date))
+			if tmpl, err = tmpl.ParseFiles(files...); err != nil {
+				return nil, fmt.Errorf("error parsing templates: %w", err)
+			}
+		}
+		for _, name := range v.templateNames(date) {
+			if t := tmpl.Lookup(name); t != nil {
+				return t, nil
+			}
+		}
+	case err == nil && info.Mode().IsRegular():
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template: %w", err)
+		}
+		tmpl.Funcs(v.templateFuncs(tmpl, filepath.Dir(path), date))
+		if tmpl, err = tmpl.Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("error parsing template %s: %w", path, err)
+		}
+		return tmpl, nil
+	}
+
+	return template.New("default").Parse(defaultTemplate)
+}
+
+// templateFuncs are the functions available in templates:
+//
+//	weekday "mon" "fri"   true if the period starts on one of these days
+//	monthday 1 15 -1      true if it starts on one of these days of the month,
+//	                      negative days counting back from the end
+//	mod .ISOWeek 2        remainder, for every-other-week sections
+//	addDays 7 .Date       date arithmetic, for links to other periods
+//	include "standup.md"  renders another file, relative to the template, with
+//	                      the period's data or the data given after the name
+//
+// weekday and monthday are checked against the first day of the period.
+func (v *Vault) templateFuncs(root *template.Template, dir string, date time.Time) template.FuncMap {
+	start, _ := v.periodBounds(date)
+	return template.FuncMap{
+		"weekday": func(days ...string) bool {
+			for _, day := range days {
+				name := strings.ToLower(start.Weekday().String())
+				if day = strings.ToLower(day); day != "" && strings.HasPrefix(name, day) {
+					return true
+				}
+			}
+			return false
+		},
+		"monthday": func(days ...int) bool {
+			last := start.AddDate(0, 1, -start.Day()).Day()
+			for _, day := range days {
+				if day == start.Day() || (day < 0 && last+day+1 == start.Day()) {
+					return true
+				}
+			}
+			return false
+		},
+		"mod": func(a, b int) int {
+			return a % b
+		},
+		"addDays": func(days int, t time.Time) time.Time {
+			return t.AddDate(0, 0, days)
+		},
+		"include": func(name string, data ...interface{}) (string, error) {
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return "", fmt.Errorf("error including %s: %w", name, err)
+			}
+			t, err := root.New(name).Parse(string(content))
+			if err != nil {
+				return "", fmt.Errorf("error parsing %s: %w", name, err)
+			}
+			var in interface{} = v.templateData(date)
+			if len(data) > 0 {
+				in = data[0]
+			}
+			var out bytes.Buffer
+			if err := t.Execute(&out, in); err != nil {
+				return "", err
+			}
+			return out.String(), nil
+		},
+	}
+}
+
+// newPeriodContent is the content a new period file for date starts with:
+// the rendered template followed by the recurring tasks due in the period.
+func (v *Vault) newPeriodContent(date time.Time) ([]byte, error) {
+	return v.periodContent(date, true)
+}
+
+// previewContent is newPeriodContent for showing a period that has no file
+// yet. It leaves out the rules due after completion, which would read the
+// whole vault every time the period is looked at.
+func (v *Vault) previewContent(date time.Time) ([]byte, error) {
+	return v.periodContent(date, false)
+}
+
+func (v *Vault) periodContent(date time.Time, completions bool) ([]byte, error) {
+	content, err := v.renderTemplate(date)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := v.recurringLines(date, content, completions)
+	if err != nil || len(lines) == 0 {
+		return content, err
+	}
+	return appendLines(content, lines), nil
+}
+
+// renderTemplate renders the template for the period of date.
+func (v *Vault) renderTemplate(date time.Time) ([]byte, error) {
+	path := v.templateFile()
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && filepath.Ext(path) != templateExt {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template: %w", err)
+		}
+		return content, nil
+	}
+
+	tmpl, err := v.loadTemplate(date)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, v.templateData(date)); err != nil {
+		return nil, fmt.Errorf("error rendering template: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// RenderTemplate returns the content a period without a file is shown with,
+// without creating it.
+func (v *Vault) RenderTemplate(date time.Time) (string, error) {
+	content, err := v.previewContent(date)
+	return string(content), err
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderTemplateDefault(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	date := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+	got, err := v.RenderTemplate(date)
+	if err != nil || got != v.GetHeader(date) {
+		t.Errorf("RenderTemplate() = %q, %v; want the header", got, err)
+	}
+}
+
+func TestRenderTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "weekly", TemplatePath: "week.tmpl"})
+	writeTemplate(t, dir, "week.tmpl", "# {{.Year}} W{{.ISOWeek}} {{.Date.Format \"Jan 2\"}}-{{.End.Format \"Jan 2\"}}\n"+
+		"{{if eq (mod .ISOWeek 2) 1}}- [ ] Sprint planning\n{{end}}{{include \"common.md\"}}")
+	writeTemplate(t, dir, "common.md", "- [ ] Timesheet for {{.Month}}\n")
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, 8, 29, 0, 0, 0, 0, time.Local), "# 2024 W35 Aug 26-Aug 31\n- [ ] Sprint planning\n- [ ] Timesheet for August\n"},
+		// Week 36 starts in August, but the file for September starts on the 1st.
+		{time.Date(2024, 9, 1, 0, 0, 0, 0, time.Local), "# 2024 W35 Sep 1-Sep 1\n- [ ] Sprint planning\n- [ ] Timesheet for September\n"},
+		{time.Date(2024, 9, 4, 0, 0, 0, 0, time.Local), "# 2024 W36 Sep 2-Sep 8\n- [ ] Timesheet for September\n"},
+	}
+	for _, tt := range tests {
+		got, err := v.RenderTemplate(tt.date)
+		if err != nil {
+			t.Fatalf("RenderTemplate(%s) error = %v", tt.date.Format(taskDateLayout), err)
+		}
+		if got != tt.want {
+			t.Errorf("RenderTemplate(%s) = %q, want %q", tt.date.Format(taskDateLayout), got, tt.want)
+		}
+	}
+}
+
+func TestRenderTemplateDirectory(t *testing.T) {
+	dir := t.TempDir()
+	templates := filepath.Join(dir, "templates")
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily", TemplatePath: "templates"})
+	writeTemplate(t, templates, "default.md", "{{.Header}}{{template \"standup.md\" .}}{{if monthday 1 -1}}- [ ] Invoices\n{{end}}")
+	writeTemplate(t, templates, "standup.md", "- [ ] Standup\n")
+	writeTemplate(t, templates, "monday.md", "{{.Header}}- [ ] Plan the week\n{{template \"standup.md\" .}}")
+
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, 8, 26, 0, 0, 0, 0, time.Local), "2024-08-26 Monday\n\n- [ ] Plan the week\n- [ ] Standup\n"},
+		{time.Date(2024, 8, 27, 0, 0, 0, 0, time.Local), "2024-08-27 Tuesday\n\n- [ ] Standup\n"},
+		{time.Date(2024, 8, 31, 0, 0, 0, 0, time.Local), "2024-08-31 Saturday\n\n- [ ] Standup\n- [ ] Invoices\n"},
+	}
+	for _, tt := range tests {
+		got, err := v.RenderTemplate(tt.date)
+		if err != nil {
+			t.Fatalf("RenderTemplate(%s) error = %v", tt.date.Format(taskDateLayout), err)
+		}
+		if got != tt.want {
+			t.Errorf("RenderTemplate(%s) = %q, want %q", tt.date.Format(taskDateLayout), got, tt.want)
+		}
+	}
+}
+
+func TestTemplateUsedConsistently(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily", TemplatePath: "template.tmpl"})
+	writeTemplate(t, dir, "template.tmpl", "{{.Weekday}}\n{{if weekday \"fri\"}}- [ ] Review the week\n{{end}}")
+	friday := time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local)
+	saturday := friday.AddDate(0, 0, 1)
+
+	tasks, err := v.LoadLinesWithSelection(friday)
+	if err != nil || len(tasks) != 1 || tasks[0].Text != "Review the week" {
+		t.Errorf("LoadLinesWithSelection() = %v, %v", tasks, err)
+	}
+	if line, err := v.ContainsLine(friday, "Review the week"); err != nil || line != 2 {
+		t.Errorf("ContainsLine() = %d, %v; want 2", line, err)
+	}
+
+	if err := v.AddTask(friday, "New"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if got, want := readTestFile(t, v.getFilename(friday)), "Friday\n- [ ] Review the week\n- [ ] New\n"; got != want {
+		t.Errorf("AddTask() file = %q, want %q", got, want)
+	}
+
+	os.Setenv("TD_TEST_MODE", "true")
+	defer os.Unsetenv("TD_TEST_MODE")
+	if err := v.OpenEditor(saturday, 1, false); err != nil {
+		t.Fatalf("OpenEditor() error = %v", err)
+	}
+	if got, want := readTestFile(t, v.getFilename(saturday)), "Saturday\n"; got != want {
+		t.Errorf("OpenEditor() file = %q, want %q", got, want)
+	}
+}
+
+func TestRenderStaticTemplate(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily", TemplatePath: ".template"})
+	content := "## Notes\n- [ ] Fill in {{placeholders}} ^daily\n"
+	writeTemplate(t, dir, ".template", content)
+
+	got, err := v.RenderTemplate(time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local))
+	if err != nil || got != content {
+		t.Errorf("RenderTemplate() = %q, %v; want the file as it is", got, err)
+	}
+}
+
+func TestIncludeWithData(t *testing.T) {
+	dir := t.TempDir()
+	v := NewVault(Config{VaultLoc: dir, IntervalMode: "daily", TemplatePath: "day.tmpl"})
+	writeTemplate(t, dir, "day.tmpl", "{{include \"item.md\"}}{{include \"item.md\" \"Review\"}}")
+	writeTemplate(t, dir, "item.md", "- [ ] {{if eq (printf \"%T\" .) \"string\"}}{{.}}{{else}}{{.Weekday}}{{end}}\n")
+
+	got, err := v.RenderTemplate(time.Date(2024, 8, 30, 0, 0, 0, 0, time.Local))
+	if want := "- [ ] Friday\n- [ ] Review\n"; err != nil || got != want {
+		t.Errorf("RenderTemplate() = %q, %v; want %q", got, err, want)
+	}
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TimerHeartbeat is how often a running timer saves its state even without
+// a transition, which bounds what is lost when it is killed.
+const TimerHeartbeat = 30 * time.Second
+
+// suspendThreshold is the smallest gap between the wall clock and the
+// monotonic clock taken for a suspend rather than clock jitter.
+const suspendThreshold = 2 * time.Second
+
+// TimerState is a running pomodoro timer as saved in the vault, so that it
+// can be resumed after the terminal closed or the process was killed.
+type TimerState struct {
+	Cycle    Cycle         `json:"cycle"`
+	Start    time.Time     `json:"start"`     // when the current phase started
+	Paused   time.Duration `json:"paused"`    // time not counted in the phase so far
+	PausedAt time.Time     `json:"paused_at"` // set while paused
+	Pauses   []Pause       `json:"pauses,omitempty"`
+	Category string        `json:"category,omitempty"`
+
+	// The task the sessions are counted against, if any.
+	TaskDate time.Time `json:"task_date,omitempty"`
+	TaskLine string    `json:"task_line,omitempty"`
+	TaskLN   int       `json:"task_line_number,omitempty"`
+
+	Heartbeat time.Time `json:"heartbeat"` // when the state was last saved
+}
+
+// IsPaused reports whether the timer is paused.
+func (s *TimerState) IsPaused() bool {
+	return !s.PausedAt.IsZero()
+}
+
+// StartPhase starts timing the cycle's current phase at now.
+func (s *TimerState) StartPhase(now time.Time) {
+	s.Start = now
+	s.Paused = 0
+	s.PausedAt = time.Time{}
+	s.Pauses = nil
+}
+
+// Elapsed returns how long the current phase has run at now, pauses and
+// suspends excluded.
+func (s *TimerState) Elapsed(now time.Time) time.Duration {
+	elapsed := wallSub(now, s.Start) - s.Paused
+	if s.IsPaused() {
+		elapsed -= wallSub(now, s.PausedAt)
+	}
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+// Pause pauses the timer at now.
+func (s *TimerState) Pause(now time.Time) {
+	if !s.IsPaused() {
+		s.PausedAt = now
+	}
+}
+
+// Resume ends the current pause at now.
+func (s *TimerState) Resume(now time.Time) {
+	if !s.IsPaused() {
+		return
+	}
+	s.addPause(s.PausedAt, now)
+	s.PausedAt = time.Time{}
+}
+
+func (s *TimerState) addPause(start, end time.Time) {
+	s.Paused += wallSub(end, start)
+	s.Pauses = append(s.Pauses, Pause{Start: start.Round(0), End: end.Round(0)})
+}
+
+// AccountSuspend compares the wall clock with the monotonic clock between
+// two readings of time.Now taken by this process, prev and now. The
+// monotonic clock stops while the machine sleeps, so a wall clock that ran
+// ahead means a suspend, which is counted as a pause. It returns how long
+// the machine slept.
+func (s *TimerState) AccountSuspend(prev, now time.Time) time.Duration {
+	slept := wallSub(now, prev) - now.Sub(prev)
+	if slept < suspendThreshold {
+		return 0
+	}
+	s.suspend(prev, slept)
+	return slept
+}
+
+// suspend counts slept from at as a pause. A pause already running or a
+// phase waiting to start holds it anyway.
+func (s *TimerState) suspend(at time.Time, slept time.Duration) {
+	if s.IsPaused() || s.Cycle.Waiting {
+		return
+	}
+	start := at.Round(0)
+	s.addPause(start, start.Add(slept))
+}
+
+// Recover continues a timer loaded from disk at now. Nothing is known of
+// the time since the last heartbeat, so it is counted as a pause, and
+// returned.
+func (s *TimerState) Recover(now time.Time) time.Duration {
+	if s.IsPaused() || s.Cycle.Waiting || s.Heartbeat.IsZero() || !now.After(s.Heartbeat) {
+		return 0
+	}
+	s.addPause(s.Heartbeat, now)
+	return wallSub(now, s.Heartbeat)
+}
+
+// wallSub is a.Sub(b) by the wall clock, which keeps counting while the
+// machine sleeps.
+func wallSub(a, b time.Time) time.Duration {
+	return a.Round(0).Sub(b.Round(0))
+}
+
+// timerFile holds the state of the running timer.
+func (v *Vault) timerFile() string {
+	return filepath.Join(v.config.VaultLoc, "pomo.json")
+}
+
+// ClaimTimer takes the vault's timer for this process until release is
+// called or the process ends, however it ends. It reports false if another
+// process holds it, which means that timer is still running.
+func (v *Vault) ClaimTimer() (release func(), ok bool, err error) {
+	filename := v.lockFile(v.timerFile() + ".running")
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, false, fmt.Errorf("failed to create directory: %w", err)
+	}
+	lock, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	locked, err := tryLock(lock)
+	if err != nil || !locked {
+		lock.Close()
+		return nil, false, err
+	}
+	return func() {
+		unlock(lock)
+		lock.Close()
+	}, true, nil
+}
+
+// SaveTimer stores the state of the running timer, stamping its heartbeat.
+func (v *Vault) SaveTimer(s *TimerState) error {
+	s.Heartbeat = time.Now()
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	filename := v.timerFile()
+	return v.withLock(filename, func() error {
+		return writeFileAtomic(filename, append(content, '\n'))
+	})
+}
+
+// LoadTimer returns the saved timer state, or nil if there is none.
+func (v *Vault) LoadTimer() (*TimerState, error) {
+	content, err := os.ReadFile(v.timerFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s TimerState
+	if err := json.Unmarshal(content, &s); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", v.timerFile(), err)
+	}
+	s.Cycle.Config.normalize()
+	return &s, nil
+}
+
+// ClearTimer removes the saved timer state once the timer has ended.
+func (v *Vault) ClearTimer() error {
+	filename := v.timerFile()
+	return v.withLock(filename, func() error {
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
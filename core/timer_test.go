@@ -0,0 +1,136 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerState(t *testing.T) {
+	start := time.Date(2024, 8, 30, 9, 0, 0, 0, time.Local)
+	s := TimerState{Cycle: *NewCycle(CycleConfig{Work: 25 * time.Minute, LongBreakEvery: 4})}
+	s.StartPhase(start)
+
+	s.Pause(start.Add(5 * time.Minute))
+	if !s.IsPaused() {
+		t.Fatal("IsPaused() = false after Pause")
+	}
+	if got := s.Elapsed(start.Add(8 * time.Minute)); got != 5*time.Minute {
+		t.Errorf("Elapsed() while paused = %v, want 5m", got)
+	}
+	s.Resume(start.Add(10 * time.Minute))
+	if got := s.Elapsed(start.Add(12 * time.Minute)); got != 7*time.Minute {
+		t.Errorf("Elapsed() after resuming = %v, want 7m", got)
+	}
+
+	// The machine sleeps for 20 minutes from 9:15.
+	s.suspend(start.Add(15*time.Minute), 20*time.Minute)
+	if got := s.Elapsed(start.Add(40 * time.Minute)); got != 15*time.Minute {
+		t.Errorf("Elapsed() after a suspend = %v, want 15m", got)
+	}
+	if len(s.Pauses) != 2 || s.Pauses[1].End.Sub(s.Pauses[1].Start) != 20*time.Minute {
+		t.Errorf("Pauses = %v, want the suspend as a second pause", s.Pauses)
+	}
+
+	// The process is killed after its 9:42 heartbeat and resumed at 10:00.
+	s.Heartbeat = start.Add(42 * time.Minute)
+	if away := s.Recover(start.Add(60 * time.Minute)); away != 18*time.Minute {
+		t.Errorf("Recover() = %v, want 18m", away)
+	}
+	if got := s.Elapsed(start.Add(60 * time.Minute)); got != 17*time.Minute {
+		t.Errorf("Elapsed() after recovering = %v, want 17m", got)
+	}
+
+	s.StartPhase(start.Add(time.Hour))
+	if s.Paused != 0 || len(s.Pauses) != 0 || s.Elapsed(start.Add(time.Hour)) != 0 {
+		t.Errorf("StartPhase() left %v paused in %d pauses", s.Paused, len(s.Pauses))
+	}
+}
+
+func TestTimerStateSuspendWhilePaused(t *testing.T) {
+	start := time.Date(2024, 8, 30, 9, 0, 0, 0, time.Local)
+	s := TimerState{Cycle: *NewCycle(CycleConfig{Work: 25 * time.Minute})}
+	s.StartPhase(start)
+	s.Pause(start.Add(5 * time.Minute))
+	s.suspend(start.Add(6*time.Minute), 10*time.Minute)
+	s.Resume(start.Add(20 * time.Minute))
+	if got := s.Elapsed(start.Add(20 * time.Minute)); got != 5*time.Minute {
+		t.Errorf("Elapsed() = %v, want the suspend counted once, 5m", got)
+	}
+	if away := s.Recover(start.Add(time.Hour)); away != 0 {
+		t.Errorf("Recover() without a heartbeat = %v, want 0", away)
+	}
+}
+
+func TestAccountSuspend(t *testing.T) {
+	s := TimerState{Cycle: *NewCycle(CycleConfig{Work: 25 * time.Minute})}
+	prev := time.Now()
+	s.StartPhase(prev)
+	// Without a suspend the wall and monotonic clocks agree.
+	if slept := s.AccountSuspend(prev, time.Now()); slept != 0 || len(s.Pauses) != 0 {
+		t.Errorf("AccountSuspend() = %v with pauses %v, want none", slept, s.Pauses)
+	}
+}
+
+func TestSaveTimer(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	if s, err := v.LoadTimer(); err != nil || s != nil {
+		t.Fatalf("LoadTimer() without a timer = %v, %v", s, err)
+	}
+
+	start := time.Date(2024, 8, 30, 9, 0, 0, 0, time.Local)
+	s := &TimerState{
+		Cycle:    *NewCycle(CycleConfig{Work: 50 * time.Minute, ShortBreak: 10 * time.Minute, LongBreakEvery: 3}),
+		Category: "writing",
+		TaskDate: start,
+		TaskLine: "- [ ] Write report 🍅1",
+		TaskLN:   3,
+	}
+	s.Cycle.Completed = 2
+	s.StartPhase(start)
+	s.Pause(start.Add(10 * time.Minute))
+	if err := v.SaveTimer(s); err != nil {
+		t.Fatalf("SaveTimer() error = %v", err)
+	}
+	if s.Heartbeat.IsZero() {
+		t.Error("SaveTimer() did not stamp the heartbeat")
+	}
+
+	got, err := v.LoadTimer()
+	if err != nil || got == nil {
+		t.Fatalf("LoadTimer() = %v, %v", got, err)
+	}
+	if got.Cycle.Config != s.Cycle.Config || got.Cycle.Completed != 2 || got.Category != "writing" ||
+		got.TaskLine != s.TaskLine || got.TaskLN != 3 || !got.TaskDate.Equal(start) {
+		t.Errorf("LoadTimer() = %+v, want %+v", got, s)
+	}
+	if !got.IsPaused() || got.Elapsed(start.Add(time.Hour)) != 10*time.Minute {
+		t.Errorf("LoadTimer() paused %v with %v elapsed, want paused after 10m", got.IsPaused(), got.Elapsed(start.Add(time.Hour)))
+	}
+
+	if err := v.ClearTimer(); err != nil {
+		t.Fatalf("ClearTimer() error = %v", err)
+	}
+	if s, err := v.LoadTimer(); err != nil || s != nil {
+		t.Errorf("LoadTimer() after ClearTimer = %v, %v", s, err)
+	}
+	if err := v.ClearTimer(); err != nil {
+		t.Errorf("ClearTimer() without a timer error = %v", err)
+	}
+}
+
+func TestClaimTimer(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	release, ok, err := v.ClaimTimer()
+	if err != nil || !ok {
+		t.Fatalf("ClaimTimer() = %v, %v", ok, err)
+	}
+	if _, ok, err := v.ClaimTimer(); err != nil || ok {
+		t.Errorf("ClaimTimer() while claimed = %v, %v, want false", ok, err)
+	}
+	release()
+	release, ok, err = v.ClaimTimer()
+	if err != nil || !ok {
+		t.Fatalf("ClaimTimer() after release = %v, %v", ok, err)
+	}
+	release()
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"strings"
+	"time"
+)
+
+// TaskNode is a task together with the subtasks indented below it.
+type TaskNode struct {
+	Task     Task
+	Children []*TaskNode
+}
+
+// indentWidth measures leading whitespace in columns, counting a tab as four.
+func indentWidth(line string) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// setDepths sets the Depth of each task to how many tasks it is nested
+// under: a task belongs to the closest preceding task with a smaller
+// indentation, whatever mix of tabs and spaces makes it up.
+func setDepths(tasks []Task) {
+	var widths []int // indentation of the tasks the current one may be nested under
+	for i := range tasks {
+		width := indentWidth(tasks[i].Indent)
+		for len(widths) > 0 && widths[len(widths)-1] >= width {
+			widths = widths[:len(widths)-1]
+		}
+		tasks[i].Depth = len(widths)
+		widths = append(widths, width)
+	}
+}
+
+// BuildTaskTree nests tasks under the closest preceding task with a smaller
+// indentation. Each task's Depth is set to its depth in the tree.
+func BuildTaskTree(tasks []Task) []*TaskNode {
+	tasks = append([]Task(nil), tasks...)
+	setDepths(tasks)
+
+	var roots []*TaskNode
+	var stack []*TaskNode
+	for _, task := range tasks {
+		node := &TaskNode{Task: task}
+		stack = stack[:task.Depth]
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, node)
+	}
+	return roots
+}
+
+// Progress returns how many direct children are done out of how many there are.
+func (n *TaskNode) Progress() (done, total int) {
+	for _, child := range n.Children {
+		if child.Task.Selected {
+			done++
+		}
+	}
+	return done, len(n.Children)
+}
+
+func (v *Vault) LoadTaskTree(date time.Time) ([]*TaskNode, error) {
+	tasks, err := v.LoadLinesWithSelection(date)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTaskTree(tasks), nil
+}
+
+// subtreeEnd returns the index just past the last line nested under lines[i].
+// Blank lines inside the subtree are included.
+func subtreeEnd(lines []string, i int) int {
+	width := indentWidth(lines[i])
+	end := i + 1
+	for j := i + 1; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if indentWidth(lines[j]) <= width {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+// parentTaskIndex returns the index of the task lines[i] is nested under, or -1.
+func parentTaskIndex(lines []string, i int) int {
+	width := indentWidth(lines[i])
+	for j := i - 1; j >= 0; j-- {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		w := indentWidth(lines[j])
+		if w >= width {
+			continue
+		}
+		if _, ok := ParseTask(lines[j], j+1); ok {
+			return j
+		}
+		width = w
+	}
+	return -1
+}
+
+// setLineStatus checks or unchecks the task on lines[i]. With cascade every
+// task nested under it gets the same status.
+func setLineStatus(lines []string, i int, selected bool, cascade bool) {
+	end := i + 1
+	if cascade {
+		end = subtreeEnd(lines, i)
+	}
+	for j := i; j < end; j++ {
+		if task, ok := ParseTask(lines[j], j+1); ok {
+			task.Selected = selected
+			lines[j] = task.String()
+		}
+	}
+}
+
+// completeParents checks the ancestors of lines[i] whose direct subtasks are all done.
+func completeParents(lines []string, i int) {
+	for parent := parentTaskIndex(lines, i); parent >= 0; parent = parentTaskIndex(lines, parent) {
+		if !childrenDone(lines, parent) {
+			return
+		}
+		setLineStatus(lines, parent, true, false)
+	}
+}
+
+func childrenDone(lines []string, i int) bool {
+	var children []Task
+	for j := i + 1; j < subtreeEnd(lines, i); j++ {
+		if task, ok := ParseTask(lines[j], j+1); ok {
+			children = append(children, task)
+		}
+	}
+	roots := BuildTaskTree(children)
+	if len(roots) == 0 {
+		return false
+	}
+	for _, child := range roots {
+		if !child.Task.Selected {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseLines(t *testing.T, lines ...string) []Task {
+	t.Helper()
+	var tasks []Task
+	for i, line := range lines {
+		task, ok := ParseTask(line, i+1)
+		if !ok {
+			t.Fatalf("ParseTask(%q) ok = false", line)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+func TestBuildTaskTree(t *testing.T) {
+	tasks := parseLines(t,
+		"- [ ] Parent",
+		"    - [x] Child 1",
+		"        - [ ] Grandchild",
+		"    - [ ] Child 2",
+		"- [x] Second",
+		"\t- [x] Tab child",
+	)
+
+	roots := BuildTaskTree(tasks)
+	if len(roots) != 2 {
+		t.Fatalf("got %d roots, want 2", len(roots))
+	}
+	parent := roots[0]
+	if len(parent.Children) != 2 || len(parent.Children[0].Children) != 1 {
+		t.Fatalf("unexpected tree shape under %q", parent.Task.Text)
+	}
+	if depth := parent.Children[0].Children[0].Task.Depth; depth != 2 {
+		t.Errorf("grandchild Depth = %d, want 2", depth)
+	}
+	if done, total := parent.Progress(); done != 1 || total != 2 {
+		t.Errorf("Progress() = %d/%d, want 1/2", done, total)
+	}
+	if done, total := roots[1].Progress(); done != 1 || total != 1 {
+		t.Errorf("Progress() = %d/%d, want 1/1", done, total)
+	}
+}
+
+func TestParseTasksDepth(t *testing.T) {
+	content := "- [ ] Parent\n    - [ ] Four spaces\n\t\t- [ ] Tabs under it\n  - [ ] Two spaces\n- [ ] Second\n"
+	tasks, err := parseTasks(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parseTasks() error = %v", err)
+	}
+	var tree []int
+	var walk func(nodes []*TaskNode)
+	walk = func(nodes []*TaskNode) {
+		for _, node := range nodes {
+			tree = append(tree, node.Task.Depth)
+			walk(node.Children)
+		}
+	}
+	walk(BuildTaskTree(tasks))
+
+	want := []int{0, 1, 2, 1, 0}
+	for i, task := range tasks {
+		if task.Depth != want[i] || tree[i] != want[i] {
+			t.Errorf("%q Depth = %d, in the tree %d, want %d", task.Text, task.Depth, tree[i], want[i])
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, v *Vault, date time.Time, content string) string {
+	t.Helper()
+	filename := v.getFilename(date)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	return filename
+}
+
+func readTestFile(t *testing.T, filename string) string {
+	t.Helper()
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	return string(content)
+}
+
+func TestUpdateTaskStatusCascade(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Parent\n  - [ ] Child 1\n\n    - [ ] Grandchild\n  - [ ] Child 2\n- [ ] Sibling\n")
+
+	parent, _ := ParseTask("- [ ] Parent", 1)
+	if err := v.UpdateTaskStatusCascade(parent.Address(testDate), true); err != nil {
+		t.Fatalf("UpdateTaskStatusCascade() error = %v", err)
+	}
+
+	want := "- [x] Parent\n  - [x] Child 1\n\n    - [x] Grandchild\n  - [x] Child 2\n- [ ] Sibling\n"
+	if got := readTestFile(t, filename); got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
+
+func TestAutoCompleteParents(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily", AutoCompleteParents: true})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := writeTestFile(t, v, testDate, "- [ ] Parent\n  - [x] Child 1\n  - [ ] Child 2\n    - [ ] Grandchild\n")
+
+	grandchild, _ := ParseTask("    - [ ] Grandchild", 4)
+	if err := v.UpdateTaskStatus(grandchild.Address(testDate), true); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+
+	want := "- [x] Parent\n  - [x] Child 1\n  - [x] Child 2\n    - [x] Grandchild\n"
+	if got := readTestFile(t, filename); got != want {
+		t.Errorf("File content = %q, want %q", got, want)
+	}
+}
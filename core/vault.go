@@ -2,58 +2,70 @@ package core
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
-var vaultLoc string
-var intervalMode string //daily, weekly or monthly
-var templatePath string
-var skipWeekend bool
+// Vault is a directory of markdown task files, one file per period.
+type Vault struct {
+	config Config
+}
 
-type Task struct {
-	Line     string
-	Selected bool
+func NewVault(config Config) *Vault {
+	return &Vault{config: config}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+func (v *Vault) Config() Config {
+	return v.config
 }
 
-func templateFile() string {
-	return filepath.Join(vaultLoc, templatePath)
+var defaultVault *Vault
+
+// defaultVaultErr is an invalid TD_* variable the default vault was built
+// without. The package-level functions that can fail return it.
+var defaultVaultErr error
+
+// DefaultVault returns the vault used by the package-level functions.
+func DefaultVault() *Vault {
+	return defaultVault
+}
+
+func SetDefaultVault(v *Vault) {
+	defaultVault = v
+	defaultVaultErr = nil
 }
 
 func init() {
-	vaultLoc = getEnv("TD_VAULT_LOC", ".td")
-	intervalMode = getEnv("TD_INTERVAL_MODE", "weekly")
-	templatePath = getEnv("TD_TEMPLATE_PATH", ".template")
-	skipWeekend = getEnv("TD_SKIP_WEEKEND", "false") == "true"
+	config, err := ConfigFromEnv()
+	defaultVault = NewVault(config)
+	defaultVaultErr = err
+}
+
+func (v *Vault) templateFile() string {
+	return filepath.Join(v.config.VaultLoc, v.config.TemplatePath)
 }
 
-func getFilename(date time.Time) string {
+func (v *Vault) getFilename(date time.Time) string {
 	year, week := date.ISOWeek()
 	month := date.Month().String()
-	if intervalMode == "daily" {
-		return filepath.Join(vaultLoc, date.Format("2006/January/02.md"))
-	} else if intervalMode == "weekly" {
-		return fmt.Sprintf("%s/%d/%s/week%d.md", vaultLoc, year, month, week)
+	if v.config.IntervalMode == "daily" {
+		return filepath.Join(v.config.VaultLoc, date.Format("2006/January/02.md"))
+	} else if v.config.IntervalMode == "weekly" {
+		return fmt.Sprintf("%s/%d/%s/week%d.md", v.config.VaultLoc, year, month, week)
 	}
 
-	return filepath.Join(vaultLoc, strconv.Itoa(year), month, month+".md")
+	return filepath.Join(v.config.VaultLoc, strconv.Itoa(year), month, month+".md")
 }
 
-func GetHeader(date time.Time) string {
-	if intervalMode == "daily" {
+func (v *Vault) GetHeader(date time.Time) string {
+	if v.config.IntervalMode == "daily" {
 		return date.Format("2006-01-02") + " " + date.Weekday().String() + "\n\n"
 	} else {
 		_, week := date.ISOWeek()
@@ -61,175 +73,134 @@ func GetHeader(date time.Time) string {
 	}
 }
 
-func NextDate(date time.Time) time.Time {
-	if intervalMode == "daily" {
+func (v *Vault) NextDate(date time.Time) time.Time {
+	if v.config.IntervalMode == "daily" {
 		next := date.AddDate(0, 0, 1)
-		if skipWeekend {
+		if v.config.SkipWeekend {
 			for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
 				next = next.AddDate(0, 0, 1)
 			}
 		}
 		return next
-	} else if intervalMode == "weekly" {
+	} else if v.config.IntervalMode == "weekly" {
 		return date.AddDate(0, 0, 7)
 	}
 	return date.AddDate(0, 1, 0) // Monthly mode
 }
 
-func PreviousDate(date time.Time) time.Time {
-	if intervalMode == "daily" {
+func (v *Vault) PreviousDate(date time.Time) time.Time {
+	if v.config.IntervalMode == "daily" {
 		prev := date.AddDate(0, 0, -1)
-		if skipWeekend {
+		if v.config.SkipWeekend {
 			for prev.Weekday() == time.Saturday || prev.Weekday() == time.Sunday {
 				prev = prev.AddDate(0, 0, -1)
 			}
 		}
 		return prev
-	} else if intervalMode == "weekly" {
+	} else if v.config.IntervalMode == "weekly" {
 		return date.AddDate(0, 0, -7)
 	}
 	return date.AddDate(0, -1, 0) // Monthly mode
 }
 
-func openFile(date time.Time) (*os.File, error) {
-	filename := getFilename(date)
-	return os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-}
-
 func fileExists(filename string) bool {
 	_, err := os.Stat(filename)
 	return err == nil
 }
 
-func createFile(path string) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+func (v *Vault) AddTask(date time.Time, line string) error {
+	line = NewTask(line).String()
+
+	if err := v.ensurePeriod(date); err != nil {
+		return err
 	}
+	return v.updateFile(date, func(content []byte) ([]byte, error) {
+		return appendLines(content, []string{line}), nil
+	})
+}
 
-	if fileExists(templateFile()) {
-		cmd := exec.Command("cp", templateFile(), path)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to copy file: %w", err)
+// linesWithSelection parses the tasks of the period file for date, or of
+// the rendered template if the file does not exist yet.
+func (v *Vault) linesWithSelection(date time.Time) ([]Task, error) {
+	var r io.Reader
+	filename := v.getFilename(date)
+	if fileExists(filename) {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
 		}
+		defer file.Close()
+		r = file
 	} else {
-		file, err := os.Create(path)
+		content, err := v.previewContent(date)
 		if err != nil {
-			return fmt.Errorf("failed to create file: %w", err)
+			return nil, err
 		}
-		defer file.Close()
+		r = bytes.NewReader(content)
 	}
-	return nil
-}
-
-func AddTask(date time.Time, line string) error {
-	line = "- [ ] " + line
 
-	filename := getFilename(date)
-	if !fileExists(filename) {
-		createFile(filename)
-	}
-	file, err := openFile(date)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(line + "\n")
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return parseTasks(r)
 }
 
-func isLineCheckbox(line string) (bool, bool) {
-	pattern := regexp.MustCompile(`^([\t ]*)- \[( |x)\] ?(.*)$`)
-	if matches := pattern.FindStringSubmatch(line); matches != nil {
-		return true, matches[2] == "x"
-	}
-	return false, false
-}
-
-func linesWithSelection(filename string) ([]Task, error) {
+// parseTasks parses the non-empty tasks of a period file.
+func parseTasks(r io.Reader) ([]Task, error) {
 	var tasks []Task
-
-	if !fileExists(filename) {
-		if fileExists(templateFile()) {
-			return linesWithSelection(templateFile())
-		} else {
-			return tasks, nil
-		}
-	}
-
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 		trimmedLine := strings.TrimSpace(line)
 
 		if trimmedLine == "" || trimmedLine == "- [ ]" || trimmedLine == "- [x]" {
 			continue
 		}
-		isCheck, selected := isLineCheckbox(line)
-		if isCheck {
-			tasks = append(tasks, Task{line, selected})
+		if task, ok := ParseTask(line, lineNumber); ok {
+			tasks = append(tasks, task)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return tasks, fmt.Errorf("error reading file: %v", err)
 	}
+	setDepths(tasks)
 	return tasks, nil
 }
 
-func LoadLinesWithSelection(date time.Time) ([]Task, error) {
-	filename := getFilename(date)
-	return linesWithSelection(filename)
-}
-
-var copyPreviousEnv bool
-
-func init() {
-	copyPreviousEnv = getEnv("TD_COPY_PREVIOUS", "false") == "true"
+func (v *Vault) LoadLinesWithSelection(date time.Time) ([]Task, error) {
+	return v.linesWithSelection(date)
 }
 
-func OpenEditor(date time.Time, lineNumber int, copyPrevious bool) error {
-	filename := getFilename(date)
-
-	// Ensure the directory exists
-	dir := filepath.Dir(filename)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
+func (v *Vault) OpenEditor(date time.Time, lineNumber int, copyPrevious bool) error {
+	filename := v.getFilename(date)
 
 	// Create the file if it doesn't exist
 	if !fileExists(filename) {
-		var content string
-		if copyPrevious || copyPreviousEnv {
-			prevDate := PreviousDate(date)
-			prevFilename := getFilename(prevDate)
-			if fileExists(prevFilename) {
-				prevContent, err := os.ReadFile(prevFilename)
-				if err != nil {
-					return fmt.Errorf("failed to read previous file: %w", err)
-				}
-				content = string(prevContent)
+		err := v.withLock(filename, func() error {
+			if fileExists(filename) {
+				return nil
+			}
+			content, err := v.newPeriodContent(date)
+			if err != nil {
+				return err
 			}
+			return replaceFile(filename, nil, content)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
 		}
 
-		// Add the header
-		header := GetHeader(date)
-		content = header + content
-
-		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
-			return fmt.Errorf("failed to create file: %w", err)
+		// Carry over the open tasks of the previous period
+		mode := v.config.Rollover
+		if (copyPrevious || v.config.CopyPrevious) && mode != "move" {
+			mode = "copy"
+		}
+		if mode == "copy" || mode == "move" {
+			opts := RolloverOptions{Move: mode == "move", Annotate: v.config.RolloverAnnotate}
+			if _, err := v.Rollover(date, opts); err != nil {
+				return fmt.Errorf("failed to carry over previous tasks: %w", err)
+			}
 		}
 	}
 
@@ -237,6 +208,27 @@ func OpenEditor(date time.Time, lineNumber int, copyPrevious bool) error {
 	if os.Getenv("TD_TEST_MODE") == "true" {
 		return nil
 	}
+	return v.editFile(filename, lineNumber)
+}
+
+// editFile runs the editor on a copy of filename and stores the result. The
+// file is not locked while the editor runs, so other td processes can still
+// write to it; if one did, the edited version wins and the other is kept as
+// the backup, reported as an ErrConflict.
+func (v *Vault) editFile(filename string, lineNumber int) error {
+	original, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "td-edit")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	copyName := filepath.Join(dir, filepath.Base(filename))
+	if err := os.WriteFile(copyName, original, 0644); err != nil {
+		return err
+	}
 
 	// Get the editor from the EDITOR environment variable, defaulting to "vim"
 	editor := os.Getenv("EDITOR")
@@ -245,7 +237,7 @@ func OpenEditor(date time.Time, lineNumber int, copyPrevious bool) error {
 	}
 
 	// Prepare the command
-	args := []string{fmt.Sprintf("+%d", lineNumber), filename}
+	args := []string{fmt.Sprintf("+%d", lineNumber), copyName}
 	cmd := exec.Command(editor, args...)
 
 	// Set the command's standard input, output, and error to the current program's ones
@@ -254,66 +246,64 @@ func OpenEditor(date time.Time, lineNumber int, copyPrevious bool) error {
 	cmd.Stderr = os.Stderr
 
 	// Run the command and wait for it to finish
-	err := cmd.Run()
-	if err != nil {
+	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("error running editor: %w", err)
 	}
 
-	return nil
-}
-
-func UpdateTaskStatus(selected bool, taskDescription string, date time.Time) error {
-	filename := getFilename(date)
-	if !fileExists(filename) {
-		createFile(filename)
-	}
-
-	file, err := os.ReadFile(filename)
+	edited, err := os.ReadFile(copyName)
 	if err != nil {
-		return fmt.Errorf("error reading file: %w", err)
-	}
-
-	lines := strings.Split(string(file), "\n")
-
-	lineUpdated := false
-	for i, line := range lines {
-		if strings.Contains(line, taskDescription) {
-			if selected {
-				lines[i] = strings.Replace(line, "- [ ]", "- [x]", 1)
-			} else {
-				lines[i] = strings.Replace(line, "- [x]", "- [ ]", 1)
-			}
-			lineUpdated = true
-			break
-		}
-	}
-
-	if !lineUpdated {
-		return fmt.Errorf("task not found in the file")
+		return fmt.Errorf("error reading edited file: %w", err)
 	}
-
-	updatedContent := strings.Join(lines, "\n")
-
-	err = os.WriteFile(filename, []byte(updatedContent), 0644)
-	if err != nil {
-		return fmt.Errorf("error writing to file: %v", err)
+	if bytes.Equal(edited, original) {
+		return nil
 	}
+	return v.withLock(filename, func() error {
+		current, err := os.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+		if err := replaceFile(filename, current, edited); err != nil {
+			return err
+		}
+		if !bytes.Equal(current, original) {
+			return fmt.Errorf("%w: %s changed while it was being edited, the other version is in %s",
+				ErrConflict, filename, filename+backupSuffix)
+		}
+		return nil
+	})
+}
 
-	return nil
+// UpdateTaskStatus checks or unchecks the task at addr. It fails with
+// ErrConflict if the line changed since the task was read.
+func (v *Vault) UpdateTaskStatus(addr TaskAddress, selected bool) error {
+	return v.updateTaskStatus(addr, selected, false)
 }
 
-func ContainsLine(date time.Time, searchLine string) (int, error) {
-	filename := getFilename(date)
+// UpdateTaskStatusCascade is like UpdateTaskStatus but also applies the
+// status to every subtask of the addressed task.
+func (v *Vault) UpdateTaskStatusCascade(addr TaskAddress, selected bool) error {
+	return v.updateTaskStatus(addr, selected, true)
+}
 
-	if !fileExists(filename) {
-		return containsLine(templateFile(), searchLine)
-	}
-	return containsLine(filename, searchLine)
+func (v *Vault) updateTaskStatus(addr TaskAddress, selected bool, cascade bool) error {
+	return v.editTask(addr, func(lines []string, i int) ([]string, error) {
+		setLineStatus(lines, i, selected, cascade)
+		if selected && v.config.AutoCompleteParents {
+			completeParents(lines, i)
+		}
+		return lines, nil
+	})
 }
 
-func containsLine(filename string, searchLine string) (int, error) {
+func (v *Vault) ContainsLine(date time.Time, searchLine string) (int, error) {
+	filename := v.getFilename(date)
+
 	if !fileExists(filename) {
-		return 0, nil
+		content, err := v.previewContent(date)
+		if err != nil {
+			return 0, err
+		}
+		return containsLine(bytes.NewReader(content), searchLine)
 	}
 
 	file, err := os.Open(filename)
@@ -321,8 +311,11 @@ func containsLine(filename string, searchLine string) (int, error) {
 		return 0, err
 	}
 	defer file.Close()
+	return containsLine(file, searchLine)
+}
 
-	scanner := bufio.NewScanner(file)
+func containsLine(r io.Reader, searchLine string) (int, error) {
+	scanner := bufio.NewScanner(r)
 	lineNumber := 1
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -339,3 +332,59 @@ func containsLine(filename string, searchLine string) (int, error) {
 
 	return 0, nil
 }
+
+// The functions below operate on the default vault.
+
+func GetHeader(date time.Time) string {
+	return defaultVault.GetHeader(date)
+}
+
+func NextDate(date time.Time) time.Time {
+	return defaultVault.NextDate(date)
+}
+
+func PreviousDate(date time.Time) time.Time {
+	return defaultVault.PreviousDate(date)
+}
+
+func AddTask(date time.Time, line string) error {
+	if defaultVaultErr != nil {
+		return defaultVaultErr
+	}
+	return defaultVault.AddTask(date, line)
+}
+
+func LoadLinesWithSelection(date time.Time) ([]Task, error) {
+	if defaultVaultErr != nil {
+		return nil, defaultVaultErr
+	}
+	return defaultVault.LoadLinesWithSelection(date)
+}
+
+func OpenEditor(date time.Time, lineNumber int, copyPrevious bool) error {
+	if defaultVaultErr != nil {
+		return defaultVaultErr
+	}
+	return defaultVault.OpenEditor(date, lineNumber, copyPrevious)
+}
+
+func UpdateTaskStatus(addr TaskAddress, selected bool) error {
+	if defaultVaultErr != nil {
+		return defaultVaultErr
+	}
+	return defaultVault.UpdateTaskStatus(addr, selected)
+}
+
+func UpdateTaskStatusCascade(addr TaskAddress, selected bool) error {
+	if defaultVaultErr != nil {
+		return defaultVaultErr
+	}
+	return defaultVault.UpdateTaskStatusCascade(addr, selected)
+}
+
+func ContainsLine(date time.Time, searchLine string) (int, error) {
+	if defaultVaultErr != nil {
+		return 0, defaultVaultErr
+	}
+	return defaultVault.ContainsLine(date, searchLine)
+}
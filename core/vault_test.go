@@ -8,16 +8,6 @@ import (
 )
 
 func TestGetFilename(t *testing.T) {
-	// Save the original values
-	originalVaultLoc := vaultLoc
-	originalIntervalMode := intervalMode
-
-	// Restore the original values after the test
-	defer func() {
-		vaultLoc = originalVaultLoc
-		intervalMode = originalIntervalMode
-	}()
-
 	tests := []struct {
 		name         string
 		vaultLoc     string
@@ -50,9 +40,8 @@ func TestGetFilename(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			vaultLoc = tt.vaultLoc
-			intervalMode = tt.intervalMode
-			got := getFilename(tt.date)
+			v := NewVault(Config{VaultLoc: tt.vaultLoc, IntervalMode: tt.intervalMode})
+			got := v.getFilename(tt.date)
 			if got != tt.want {
 				t.Errorf("getFilename() = %v, want %v", got, tt.want)
 			}
@@ -61,14 +50,6 @@ func TestGetFilename(t *testing.T) {
 }
 
 func TestGetHeader(t *testing.T) {
-	// Save the original value
-	originalIntervalMode := intervalMode
-
-	// Restore the original value after the test
-	defer func() {
-		intervalMode = originalIntervalMode
-	}()
-
 	tests := []struct {
 		name         string
 		intervalMode string
@@ -91,8 +72,8 @@ func TestGetHeader(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			intervalMode = tt.intervalMode
-			got := GetHeader(tt.date)
+			v := NewVault(Config{IntervalMode: tt.intervalMode})
+			got := v.GetHeader(tt.date)
 			if got != tt.want {
 				t.Errorf("GetHeader() = %v, want %v", got, tt.want)
 			}
@@ -109,13 +90,11 @@ func TestAddTask(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// Set up the test environment
-	vaultLoc = tempDir
-	intervalMode = "daily"
-	templatePath = "test_template"
+	v := NewVault(Config{VaultLoc: tempDir, IntervalMode: "daily", TemplatePath: "test_template"})
 
 	// Create a test template file
 	templateContent := "Template content\n"
-	err = os.WriteFile(filepath.Join(tempDir, templatePath), []byte(templateContent), 0644)
+	err = os.WriteFile(filepath.Join(tempDir, "test_template"), []byte(templateContent), 0644)
 	if err != nil {
 		t.Fatalf("Failed to create test template: %v", err)
 	}
@@ -123,13 +102,13 @@ func TestAddTask(t *testing.T) {
 	// Test adding a task
 	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
 	testTask := "Test task"
-	err = AddTask(testDate, testTask)
+	err = v.AddTask(testDate, testTask)
 	if err != nil {
 		t.Errorf("AddTask() error = %v", err)
 	}
 
 	// Verify the task was added correctly
-	filename := getFilename(testDate)
+	filename := v.getFilename(testDate)
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		t.Fatalf("Failed to read file: %v", err)
@@ -150,12 +129,11 @@ func TestUpdateTaskStatus(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// Set up the test environment
-	vaultLoc = tempDir
-	intervalMode = "daily"
+	v := NewVault(Config{VaultLoc: tempDir, IntervalMode: "daily"})
 
 	// Create a test file with tasks
 	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
-	filename := getFilename(testDate)
+	filename := v.getFilename(testDate)
 	initialContent := "- [ ] Task 1\n- [ ] Task 2\n- [ ] Task 3\n"
 	err = os.MkdirAll(filepath.Dir(filename), 0755)
 	if err != nil {
@@ -167,7 +145,8 @@ func TestUpdateTaskStatus(t *testing.T) {
 	}
 
 	// Test updating task status
-	err = UpdateTaskStatus(true, "Task 2", testDate)
+	task, _ := ParseTask("- [ ] Task 2", 2)
+	err = v.UpdateTaskStatus(task.Address(testDate), true)
 	if err != nil {
 		t.Errorf("UpdateTaskStatus() error = %v", err)
 	}
@@ -185,15 +164,7 @@ func TestUpdateTaskStatus(t *testing.T) {
 }
 
 func TestNextDateWithWeekendSkipping(t *testing.T) {
-	originalIntervalMode := intervalMode
-	originalSkipWeekend := skipWeekend
-	defer func() {
-		intervalMode = originalIntervalMode
-		skipWeekend = originalSkipWeekend
-	}()
-
-	intervalMode = "daily"
-	skipWeekend = true
+	v := NewVault(Config{IntervalMode: "daily", SkipWeekend: true})
 
 	tests := []struct {
 		name string
@@ -224,7 +195,7 @@ func TestNextDateWithWeekendSkipping(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NextDate(tt.date)
+			got := v.NextDate(tt.date)
 			if !got.Equal(tt.want) {
 				t.Errorf("NextDate() = %v, want %v", got, tt.want)
 			}
@@ -233,15 +204,7 @@ func TestNextDateWithWeekendSkipping(t *testing.T) {
 }
 
 func TestPreviousDateWithWeekendSkipping(t *testing.T) {
-	originalIntervalMode := intervalMode
-	originalSkipWeekend := skipWeekend
-	defer func() {
-		intervalMode = originalIntervalMode
-		skipWeekend = originalSkipWeekend
-	}()
-
-	intervalMode = "daily"
-	skipWeekend = true
+	v := NewVault(Config{IntervalMode: "daily", SkipWeekend: true})
 
 	tests := []struct {
 		name string
@@ -272,7 +235,7 @@ func TestPreviousDateWithWeekendSkipping(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := PreviousDate(tt.date)
+			got := v.PreviousDate(tt.date)
 			if !got.Equal(tt.want) {
 				t.Errorf("PreviousDate() = %v, want %v", got, tt.want)
 			}
@@ -293,14 +256,12 @@ func TestOpenEditorWithCopyPrevious(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// Set up the test environment
-	vaultLoc = tempDir
-	intervalMode = "daily"
-	skipWeekend = false
+	v := NewVault(Config{VaultLoc: tempDir, IntervalMode: "daily"})
 
 	// Create a previous day's file
 	prevDate := time.Date(2024, 8, 29, 0, 0, 0, 0, time.UTC)
-	prevFilename := getFilename(prevDate)
-	prevContent := "Previous day's content\n"
+	prevFilename := v.getFilename(prevDate)
+	prevContent := "2024-08-29 Thursday\n\n- [x] Done task\n- [ ] Open task\n"
 	err = os.MkdirAll(filepath.Dir(prevFilename), 0755)
 	if err != nil {
 		t.Fatalf("Failed to create directories: %v", err)
@@ -312,39 +273,87 @@ func TestOpenEditorWithCopyPrevious(t *testing.T) {
 
 	// Test OpenEditor with copyPrevious = true
 	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
-	err = OpenEditor(testDate, 1, true)
+	err = v.OpenEditor(testDate, 1, true)
 	if err != nil {
 		t.Errorf("OpenEditor() error = %v", err)
 	}
 
-	// Verify the new file was created with the previous day's content and the new header
-	filename := getFilename(testDate)
+	// Verify the new file was created with the new header and the previous day's open tasks
+	filename := v.getFilename(testDate)
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		t.Fatalf("Failed to read file: %v", err)
 	}
 
-	expectedContent := GetHeader(testDate) + prevContent
+	expectedContent := v.GetHeader(testDate) + "- [ ] Open task\n"
 	if string(content) != expectedContent {
 		t.Errorf("File content = %v, want %v", string(content), expectedContent)
 	}
 
 	// Test OpenEditor with copyPrevious = false
 	testDate = time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC)
-	err = OpenEditor(testDate, 1, false)
+	err = v.OpenEditor(testDate, 1, false)
 	if err != nil {
 		t.Errorf("OpenEditor() error = %v", err)
 	}
 
 	// Verify the new file was created with only the header
-	filename = getFilename(testDate)
+	filename = v.getFilename(testDate)
 	content, err = os.ReadFile(filename)
 	if err != nil {
 		t.Fatalf("Failed to read file: %v", err)
 	}
 
-	expectedContent = GetHeader(testDate)
+	expectedContent = v.GetHeader(testDate)
 	if string(content) != expectedContent {
 		t.Errorf("File content = %v, want %v", string(content), expectedContent)
 	}
 }
+
+func TestVaultsAreIndependent(t *testing.T) {
+	daily := NewVault(Config{VaultLoc: "/a", IntervalMode: "daily"})
+	monthly := NewVault(Config{VaultLoc: "/b", IntervalMode: "monthly"})
+
+	date := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	if got, want := daily.getFilename(date), "/a/2024/August/30.md"; got != want {
+		t.Errorf("daily getFilename() = %v, want %v", got, want)
+	}
+	if got, want := monthly.getFilename(date), "/b/2024/August/August.md"; got != want {
+		t.Errorf("monthly getFilename() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadLinesWithSelection(t *testing.T) {
+	tempDir := t.TempDir()
+	v := NewVault(Config{VaultLoc: tempDir, IntervalMode: "daily"})
+
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+	filename := v.getFilename(testDate)
+	content := "2024-08-30 Friday\n\n- [ ] Task 1 #work\n  - [x] Subtask\n- [ ]\nnotes\n- [ ] Task 2\n"
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tasks, err := v.LoadLinesWithSelection(testDate)
+	if err != nil {
+		t.Fatalf("LoadLinesWithSelection() error = %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("got %d tasks, want 3", len(tasks))
+	}
+	wantLines := []int{3, 4, 7}
+	for i, task := range tasks {
+		if task.LineNumber != wantLines[i] {
+			t.Errorf("tasks[%d].LineNumber = %d, want %d", i, task.LineNumber, wantLines[i])
+		}
+	}
+	if tasks[1].Depth != 1 || !tasks[1].Selected || tasks[1].Text != "Subtask" {
+		t.Errorf("tasks[1] = %+v", tasks[1])
+	}
+	if !tasks[0].HasTag("work") {
+		t.Errorf("tasks[0].Tags = %v, want work", tasks[0].Tags)
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// PeriodWatcher reports changes to the files of one or more periods, whether
+// made by another td process or by an editor.
+//
+// The directories holding the files are watched rather than the files
+// themselves, as atomic writes replace them. If a directory does not exist
+// yet its closest existing ancestor is watched until it is created.
+type PeriodWatcher struct {
+	vault   *Vault
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+
+	mu        sync.Mutex
+	filenames map[string]bool
+	watched   map[string]bool
+}
+
+func (v *Vault) NewPeriodWatcher(dates ...time.Time) (*PeriodWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &PeriodWatcher{
+		vault:   v,
+		watcher: watcher,
+		changes: make(chan struct{}, 1),
+		watched: map[string]bool{},
+	}
+	if err := w.Watch(dates...); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Changes receives a value whenever the watched file changed. Bursts of
+// changes are coalesced. The channel is closed by Close.
+func (w *PeriodWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Watch switches the watcher to the files of the periods containing dates.
+func (w *PeriodWatcher) Watch(dates ...time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.filenames = map[string]bool{}
+	for _, date := range dates {
+		w.filenames[w.vault.getFilename(date)] = true
+	}
+	_, err := w.retarget()
+	return err
+}
+
+func (w *PeriodWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// retarget watches the closest existing directory on the way to each file
+// and stops watching directories no longer needed. It reports whether the
+// set of watched directories changed. It must be called with mu held.
+func (w *PeriodWatcher) retarget() (bool, error) {
+	needed := map[string]bool{}
+	for filename := range w.filenames {
+		dir := filepath.Dir(filename)
+		for !isDir(dir) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+		needed[dir] = true
+	}
+
+	changed := false
+	for dir := range w.watched {
+		if !needed[dir] {
+			w.watcher.Remove(dir)
+			delete(w.watched, dir)
+			changed = true
+		}
+	}
+	for dir := range needed {
+		if w.watched[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			return changed, err
+		}
+		w.watched[dir] = true
+		changed = true
+	}
+	return changed, nil
+}
+
+func (w *PeriodWatcher) run() {
+	defer close(w.changes)
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.handle(event) {
+				select {
+				case w.changes <- struct{}{}:
+				default:
+				}
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// handle reports whether event affects the watched file.
+func (w *PeriodWatcher) handle(event fsnotify.Event) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.filenames[event.Name] {
+		return true
+	}
+	// A directory on the way to a file appeared: move the watch closer.
+	if event.Has(fsnotify.Create) && isDir(event.Name) {
+		changed, _ := w.retarget()
+		return changed
+	}
+	return false
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func expectChange(t *testing.T, w *PeriodWatcher) {
+	t.Helper()
+	select {
+	case <-w.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no change reported")
+	}
+}
+
+func TestPeriodWatcher(t *testing.T) {
+	v := NewVault(Config{VaultLoc: t.TempDir(), IntervalMode: "daily"})
+	testDate := time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)
+
+	// The period directory does not exist yet.
+	w, err := v.NewPeriodWatcher(testDate)
+	if err != nil {
+		t.Fatalf("NewPeriodWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	if err := v.AddTask(testDate, "Task 1"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	expectChange(t, w)
+
+	// Drain any further events from the first write.
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-w.Changes():
+	default:
+	}
+
+	// Changes to other periods are not reported.
+	if err := v.AddTask(testDate.AddDate(0, 0, 1), "Other day"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	select {
+	case <-w.Changes():
+		t.Errorf("change reported for another period")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := v.AddTask(testDate, "Task 2"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	expectChange(t, w)
+
+	// After watching both days, the other one is reported too.
+	if err := w.Watch(testDate, testDate.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-w.Changes():
+	default:
+	}
+	if err := v.AddTask(testDate.AddDate(0, 0, 1), "Other day again"); err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	expectChange(t, w)
+}